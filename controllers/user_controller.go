@@ -1,9 +1,12 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"go-socket-server/models"
 	"go-socket-server/services"
+	"strings"
+	"time"
 )
 
 type UserController struct {
@@ -15,18 +18,27 @@ func NewUserController(userService *services.UserService) *UserController {
 	return &UserController{userService: userService}
 }
 
+// MaxBlogBytes returns the largest blog body the server accepts
+func (uc *UserController) MaxBlogBytes() int {
+	return uc.userService.Config().MaxBlogBytes
+}
+
 // --- User Management ---
 
-// Register allows a new user to register with a username, password, role, and status
-func (uc *UserController) Register(username, password, role, status string) string {
-	err := uc.userService.RegisterUser(username, password, role, status)
+// Register allows a new user to register with a username, password and optional invite code, following the registration mode
+func (uc *UserController) Register(username, password, inviteCode string) string {
+	pending, err := uc.userService.SignUp(username, password, inviteCode)
 	if err != nil {
 		return "Error: " + err.Error()
 	}
+	if pending {
+		return "Registration received. An admin must approve your account before you can log in."
+	}
 	return "Registration successful!"
 }
 
-// Login allows a user to log in with a username and password
+// Login allows a user to log in with a username and password.
+// An account waiting for approval gets services.ErrRegistrationPending.
 func (uc *UserController) Login(username, password string) (string, bool, error) {
 	user, err := uc.userService.LoginUser(username, password)
 	if err != nil {
@@ -38,36 +50,212 @@ func (uc *UserController) Login(username, password string) (string, bool, error)
 
 // ViewProfile allows a user to view their profile
 func (uc *UserController) ViewProfile(username string) string {
-	user, err := uc.userService.FindUserByUsername(username)
+	entries, err := uc.userService.GetVisibleProfile(username, username)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	// Format profile information along with who can see each field
+	response := ""
+	for _, entry := range entries {
+		response += fmt.Sprintf("\n%s: %s [%s]", entry.Field.Label, entry.Value, entry.Visibility)
+	}
+	if missing, err := uc.userService.MissingRequiredFields(username); err == nil && len(missing) > 0 {
+		labels := []string{}
+		for _, field := range missing {
+			labels = append(labels, field.Label)
+		}
+		response += "\nPlease fill in the required fields: " + strings.Join(labels, ", ")
+	}
+	return response
+}
+
+// Profile allows a user to view another user's profile, showing only the fields they are allowed to see
+func (uc *UserController) Profile(viewer, username string) string {
+	entries, overridden, err := uc.userService.ViewProfile(viewer, username)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	response := ""
+	if overridden {
+		response = "[Admin override: some fields are not visible to you and this access has been logged.]\n"
+	}
+	response += "Profile of " + username + ":\n"
+	shown := 0
+	for _, entry := range entries {
+		if entry.Value != "" {
+			response += fmt.Sprintf("%s: %s\n", entry.Field.Label, entry.Value)
+			shown++
+		}
+	}
+	if shown == 0 {
+		response += "Nothing to show.\n"
+	}
+	return response
+}
+
+// SetProfileVisibility allows a user to choose who can see one of their profile fields
+func (uc *UserController) SetProfileVisibility(username, key, visibility string) string {
+	err := uc.userService.SetProfileVisibility(username, key, visibility)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return fmt.Sprintf("Visibility of %s set to %s.", key, visibility)
+}
+
+// GetProfileSchema returns every profile field in display order, used to drive the profile wizard
+func (uc *UserController) GetProfileSchema() []models.ProfileField {
+	return uc.userService.GetProfileSchema()
+}
+
+// ProfileFields describes every profile field and its rules
+func (uc *UserController) ProfileFields() string {
+	response := "Profile fields:\n"
+	for _, field := range uc.userService.GetProfileSchema() {
+		rules := []string{field.Type}
+		if field.Required {
+			rules = append(rules, "required")
+		}
+		if field.MaxLength > 0 {
+			rules = append(rules, fmt.Sprintf("max %d characters", field.MaxLength))
+		}
+		if field.Pattern != "" {
+			rules = append(rules, "pattern "+field.Pattern)
+		}
+		if field.Type == "number" {
+			rules = append(rules, fmt.Sprintf("%d to %d", field.Min, field.Max))
+		}
+		rules = append(rules, field.Visibility)
+		if field.BuiltIn {
+			rules = append(rules, "built-in")
+		}
+		response += fmt.Sprintf("- %s (%s): %s\n", field.Key, field.Label, strings.Join(rules, ", "))
+	}
+	return response
+}
+
+// AddProfileField allows an admin to add a custom field to the profile schema
+func (uc *UserController) AddProfileField(admin string, field models.ProfileField) string {
+	err := uc.userService.AddProfileField(admin, field)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Profile field added successfully!"
+}
+
+// RemoveProfileField allows an admin to remove a custom field from the profile schema
+func (uc *UserController) RemoveProfileField(admin, key string) string {
+	err := uc.userService.RemoveProfileField(admin, key)
 	if err != nil {
 		return "Error: " + err.Error()
 	}
-	// Format profile information
-	return fmt.Sprintf("\nName: %s\nSurname: %s\nFav Animal: %s\nFav Movie: %s\nYear of Birth: %s\nCity of Birth: %s\nFootball Team: %s",
-		user.Name, user.Surname, user.FavAnimal, user.FavMovie, user.YearOfBirth, user.CityOfBirth, user.FootballTeam)
+	return "Profile field removed: " + key
+}
+
+// GetUser returns the stored record of a user, used to show current values while editing a profile
+func (uc *UserController) GetUser(username string) (models.User, error) {
+	return uc.userService.FindUserByUsername(username)
+}
+
+// UpdateProfile allows a user to change some of their profile fields, leaving the others untouched
+func (uc *UserController) UpdateProfile(username string, changes map[string]string) string {
+	if len(changes) == 0 {
+		return "No changes made to your profile."
+	}
+	err := uc.userService.UpdateUserProfile(username, changes)
+	if err != nil {
+		return uc.profileError(err)
+	}
+	return "Profile updated successfully!"
 }
 
-// UpdateProfile allows a user to update their profile information
-func (uc *UserController) UpdateProfile(username, name, surname, favAnimal, favMovie, yearOfBirth, city, footballTeam string) string {
-	err := uc.userService.UpdateUserProfile(username, name, surname, favAnimal, favMovie, yearOfBirth, city, footballTeam)
+// SetProfileField allows a user to change a single profile field; an empty value clears it
+func (uc *UserController) SetProfileField(username, key, value string) string {
+	err := uc.userService.UpdateUserProfile(username, map[string]string{key: value})
 	if err != nil {
+		return uc.profileError(err)
+	}
+	return "Profile updated successfully!"
+}
+
+// profileError formats an error from a profile update, listing each invalid field on its own line
+func (uc *UserController) profileError(err error) string {
+	var invalid *services.ValidationError
+	if !errors.As(err, &invalid) {
 		return "Error: " + err.Error()
 	}
-	return "Profile updated successfully!" +
-		"\nReturning to main menu."
+	schema := uc.userService.GetProfileSchema()
+	response := "Error: some profile fields are invalid:"
+	for _, fieldError := range invalid.Fields {
+		label := fieldError.Field
+		for _, field := range schema {
+			if field.Key == fieldError.Field {
+				label = field.Label
+			}
+		}
+		response += fmt.Sprintf("\n- %s: %s", label, fieldError.Message)
+	}
+	return response
 }
 
 // --- Blog Management ---
 
-// PostBlog allows a user to create a blog post with a title and text
-func (uc *UserController) PostBlog(username, title, text string) string {
-	err := uc.userService.CreateBlog(username, title, text)
+// PostBlog allows a user to create a blog post with a title, text and tags, either published
+// right away, saved as a draft or scheduled for later
+func (uc *UserController) PostBlog(username, title, text string, tags []string, status, visibility string, publishAt time.Time) string {
+	err := uc.userService.CreateBlog(username, title, text, tags, status, visibility, publishAt)
 	if err != nil {
 		return "Error: " + err.Error()
 	}
+	switch status {
+	case "draft":
+		return "Draft saved successfully!"
+	case "scheduled":
+		return "Blog scheduled for " + publishAt.Format("2006-01-02 15:04") + "."
+	}
 	return "Blog posted successfully!"
 }
 
+// PublishBlog allows a user to publish one of their drafts or scheduled blogs right away
+func (uc *UserController) PublishBlog(username, blogID string) string {
+	err := uc.userService.PublishBlog(username, blogID)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Blog published successfully!"
+}
+
+// ScheduleBlog allows a user to schedule one of their unpublished blogs for later
+func (uc *UserController) ScheduleBlog(username, blogID string, publishAt time.Time) string {
+	err := uc.userService.ScheduleBlog(username, blogID, publishAt)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Blog scheduled for " + publishAt.Format("2006-01-02 15:04") + "."
+}
+
+// UnscheduleBlog allows a user to turn a scheduled blog back into a draft
+func (uc *UserController) UnscheduleBlog(username, blogID string) string {
+	err := uc.userService.UnscheduleBlog(username, blogID)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Blog moved back to drafts."
+}
+
+// SetVisibility allows a user to change who can read one of their blogs
+func (uc *UserController) SetVisibility(username, blogID, visibility string) string {
+	err := uc.userService.SetBlogVisibility(username, blogID, visibility)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Blog visibility set to " + visibility + "."
+}
+
+// PublishDueBlogs publishes every scheduled blog whose time has come and returns them
+func (uc *UserController) PublishDueBlogs() []models.Blog {
+	return uc.userService.PublishDueBlogs(time.Now())
+}
+
 // DeleteBlog allows a user to delete their own blog post
 func (uc *UserController) DeleteBlog(username, blogID string) string {
 	err := uc.userService.DeleteBlog(username, blogID)
@@ -77,6 +265,782 @@ func (uc *UserController) DeleteBlog(username, blogID string) string {
 	return "Blog deleted successfully!"
 }
 
+// EditBlog allows a user to edit their own blog post, keeping the previous version as a revision
+func (uc *UserController) EditBlog(username, blogID, title, text string) string {
+	err := uc.userService.EditBlog(username, blogID, title, text)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Blog updated successfully!"
+}
+
+// ListRevisions shows every revision of a blog post. The highest number is the current version.
+func (uc *UserController) ListRevisions(blogID string) string {
+	blog, err := uc.userService.GetBlog(blogID)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	response := "Revisions:\n"
+	for i, revision := range blog.Revisions {
+		response += fmt.Sprintf("%d. %s (replaced %s)\n", i+1, revision.Title, revision.ReplacedAt.Format("2006-01-02 15:04"))
+	}
+	response += fmt.Sprintf("%d. %s (current)\n", len(blog.Revisions)+1, blog.Title)
+	return response
+}
+
+// DiffRevisions shows the changes between two revisions of a blog post
+func (uc *UserController) DiffRevisions(blogID string, from, to int) string {
+	blog, err := uc.userService.GetBlog(blogID)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	versions := append([]models.BlogRevision{}, blog.Revisions...)
+	versions = append(versions, models.BlogRevision{Title: blog.Title, Text: blog.Text})
+	if from < 1 || from > len(versions) || to < 1 || to > len(versions) {
+		return "Error: Revision not found"
+	}
+	oldVersion, newVersion := versions[from-1], versions[to-1]
+	diff, err := diffLines("Title: "+oldVersion.Title+"\n"+oldVersion.Text, "Title: "+newVersion.Title+"\n"+newVersion.Text)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return fmt.Sprintf("Changes from revision %d to %d:\n", from, to) + diff
+}
+
+// RestoreRevision allows a user to restore an earlier revision of their own blog post
+func (uc *UserController) RestoreRevision(username, blogID string, revision int) string {
+	err := uc.userService.RestoreBlogRevision(username, blogID, revision)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return fmt.Sprintf("Revision %d restored successfully!", revision)
+}
+
+// Feed shows one page of the global blog feed, newest first
+func (uc *UserController) Feed(viewer string, page int) string {
+	blogs, pages := uc.userService.GetFeed(viewer, page)
+	return uc.formatBlogList("Feed", blogs, page, pages)
+}
+
+// UserPosts shows one page of another user's blogs, newest first
+func (uc *UserController) UserPosts(viewer, username string, page int) string {
+	blogs, pages, err := uc.userService.GetUserPosts(viewer, username, page)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return uc.formatBlogList("Blogs by "+username, blogs, page, pages)
+}
+
+// ReadPost shows a single blog post by its ID
+func (uc *UserController) ReadPost(viewer, blogID string) string {
+	blog, overridden, err := uc.userService.ReadBlog(viewer, blogID)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	notice := ""
+	if overridden {
+		notice = "[Admin override: this blog is not visible to you and this access has been logged.]\n"
+	}
+	response := fmt.Sprintf("%s\nby %s on %s", blog.Title, blog.Author, blogDate(blog))
+	if blog.Status == "published" && blog.UpdatedAt.After(blog.PublishedAt) {
+		response += fmt.Sprintf(" (edited %s)", blog.UpdatedAt.Format("2006-01-02 15:04"))
+	}
+	if len(blog.Tags) > 0 {
+		response += "\nTags: #" + strings.Join(blog.Tags, " #")
+	}
+	switch blog.Status {
+	case "draft":
+		response += "\n[draft]"
+	case "scheduled":
+		response += "\n[scheduled for " + blog.PublishAt.Format("2006-01-02 15:04") + "]"
+	}
+	if blog.Visibility != "public" {
+		response += "\n[visible to: " + blog.Visibility + "]"
+	}
+	response = notice + response + fmt.Sprintf("\n\n%s\n\n%d comments", blog.Text, uc.userService.CountComments(blog.ID))
+	if reactions := formatReactions(uc.userService.CountReactions(blog.ID)); reactions != "" {
+		response += ", " + reactions
+	}
+	return response + "\n"
+}
+
+// SetTags allows a user to replace the tags of their own blog post
+func (uc *UserController) SetTags(username, blogID string, tags []string) string {
+	err := uc.userService.SetBlogTags(username, blogID, tags)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Tags updated successfully!"
+}
+
+// PopularTags shows every tag on the blogs the viewer can read with the number of blogs carrying it
+func (uc *UserController) PopularTags(viewer string) string {
+	tags := uc.userService.GetPopularTags(viewer)
+	if len(tags) == 0 {
+		return "No tags yet."
+	}
+	response := "Popular Tags:\n"
+	for _, tag := range tags {
+		response += fmt.Sprintf("- #%s (%d)\n", tag.Tag, tag.Count)
+	}
+	return response
+}
+
+// TaggedPosts shows one page of the blogs carrying a tag, newest first
+func (uc *UserController) TaggedPosts(viewer, tag string, page int) string {
+	blogs, pages, err := uc.userService.GetBlogsByTag(viewer, tag, page)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return uc.formatBlogList("Blogs tagged #"+strings.TrimPrefix(strings.ToLower(tag), "#"), blogs, page, pages)
+}
+
+// LikedPosts shows one page of the blogs the user has liked, newest first
+func (uc *UserController) LikedPosts(username string, page int) string {
+	blogs, pages := uc.userService.GetLikedBlogs(username, page)
+	return uc.formatBlogList("Liked Blogs", blogs, page, pages)
+}
+
+// blogDate returns the date shown for a blog: when it was published, or when it was written if it is still unpublished
+func blogDate(blog models.Blog) string {
+	if blog.PublishedAt.IsZero() {
+		return blog.CreatedAt.Format("2006-01-02 15:04")
+	}
+	return blog.PublishedAt.Format("2006-01-02 15:04")
+}
+
+// formatBlogList renders a page of blog summaries with their IDs so they can be opened with "read"
+func (uc *UserController) formatBlogList(heading string, blogs []models.Blog, page, pages int) string {
+	if page < 1 {
+		page = 1
+	}
+	response := fmt.Sprintf("%s (page %d of %d):\n", heading, page, pages)
+	if len(blogs) == 0 {
+		return response + "No blogs to show.\n"
+	}
+	for _, blog := range blogs {
+		total := 0
+		for _, count := range uc.userService.CountReactions(blog.ID) {
+			total += count
+		}
+		response += fmt.Sprintf("[%s] %s by %s (%s, %d comments, %d reactions)\n",
+			blog.ID, blog.Title, blog.Author, blogDate(blog), uc.userService.CountComments(blog.ID), total)
+	}
+	return response
+}
+
+// formatReactions renders reaction counts in the fixed order of models.ReactionKinds, e.g. "like 3, wow 1"
+func formatReactions(counts map[string]int) string {
+	parts := []string{}
+	for _, kind := range models.ReactionKinds {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", kind, counts[kind]))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// --- Following ---
+
+// Follow allows a user to follow another user
+func (uc *UserController) Follow(username, followee string) string {
+	err := uc.userService.Follow(username, followee)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "You are now following " + followee + "."
+}
+
+// Unfollow allows a user to stop following another user
+func (uc *UserController) Unfollow(username, followee string) string {
+	err := uc.userService.Unfollow(username, followee)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "You are no longer following " + followee + "."
+}
+
+// Followers lists the users following a user
+func (uc *UserController) Followers(username string) string {
+	followers, err := uc.userService.GetFollowers(username)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return formatUserList(fmt.Sprintf("Followers of %s (%d):", username, len(followers)), followers)
+}
+
+// Following lists the users a user follows
+func (uc *UserController) Following(username string) string {
+	following, err := uc.userService.GetFollowing(username)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return formatUserList(fmt.Sprintf("%s follows (%d):", username, len(following)), following)
+}
+
+// Timeline shows one page of blogs from the users the viewer follows, newest first
+func (uc *UserController) Timeline(viewer string, page int) string {
+	if page < 1 {
+		page = 1
+	}
+	blogs, more := uc.userService.GetTimeline(viewer, page)
+	response := fmt.Sprintf("Timeline (page %d):\n", page)
+	if len(blogs) == 0 {
+		return response + "No blogs to show. Follow users to see their posts here.\n"
+	}
+	for _, blog := range blogs {
+		response += fmt.Sprintf("[%s] %s by %s (%s, %d comments)\n",
+			blog.ID, blog.Title, blog.Author, blogDate(blog), uc.userService.CountComments(blog.ID))
+	}
+	if more {
+		response += fmt.Sprintf("Type 'timeline %d' for older posts.\n", page+1)
+	}
+	return response
+}
+
+// formatUserList renders a heading followed by one username per line
+func formatUserList(heading string, usernames []string) string {
+	response := heading + "\n"
+	for _, username := range usernames {
+		response += "- " + username + "\n"
+	}
+	return response
+}
+
+// --- Direct Messages ---
+
+// SendMessage allows a user to send a direct message to another user
+func (uc *UserController) SendMessage(from, to, text string) string {
+	err := uc.userService.SendMessage(from, to, text)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Message sent to " + to + "."
+}
+
+// QueuedMessages shows the messages that arrived while the user was offline, or an empty string if there are none
+func (uc *UserController) QueuedMessages(username string) string {
+	messages := uc.userService.DeliverQueuedMessages(username)
+	if len(messages) == 0 {
+		return ""
+	}
+	response := fmt.Sprintf("You received %d messages while you were away:\n", len(messages))
+	for _, message := range messages {
+		response += fmt.Sprintf("[%s] %s: %s\n", message.SentAt.Format("2006-01-02 15:04"), message.From, message.Text)
+	}
+	return response
+}
+
+// Inbox shows the user's conversations with their unread counts
+func (uc *UserController) Inbox(username string) string {
+	conversations := uc.userService.GetInbox(username)
+	if len(conversations) == 0 {
+		return "Your inbox is empty."
+	}
+	unread := 0
+	for _, conversation := range conversations {
+		unread += conversation.Unread
+	}
+	response := fmt.Sprintf("Inbox (%d unread):\n", unread)
+	for _, conversation := range conversations {
+		last := conversation.LastMessage
+		response += fmt.Sprintf("- %s", conversation.With)
+		if conversation.Unread > 0 {
+			response += fmt.Sprintf(" (%d unread)", conversation.Unread)
+		}
+		response += fmt.Sprintf(": %s: %s [%s]\n", last.From, last.Text, last.SentAt.Format("2006-01-02 15:04"))
+	}
+	return response
+}
+
+// Conversation shows one page of the messages exchanged with another user and marks them as read
+func (uc *UserController) Conversation(username, other string, page int) string {
+	messages, pages, err := uc.userService.GetConversation(username, other, page)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if page < 1 {
+		page = 1
+	}
+	response := fmt.Sprintf("Conversation with %s (page %d of %d, newest last):\n", other, page, pages)
+	if len(messages) == 0 {
+		return response + "No messages.\n"
+	}
+	for _, message := range messages {
+		response += fmt.Sprintf("[%s] %s: %s\n", message.SentAt.Format("2006-01-02 15:04"), message.From, message.Text)
+	}
+	return response
+}
+
+// BlockUser allows a user to stop another user from messaging them
+func (uc *UserController) BlockUser(username, blocked string) string {
+	err := uc.userService.BlockUser(username, blocked)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return blocked + " can no longer send you messages."
+}
+
+// UnblockUser allows a user to accept messages from a previously blocked user again
+func (uc *UserController) UnblockUser(username, blocked string) string {
+	err := uc.userService.UnblockUser(username, blocked)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return blocked + " has been unblocked."
+}
+
+// BlockedUsers lists the users the user has blocked
+func (uc *UserController) BlockedUsers(username string) string {
+	blocked := uc.userService.GetBlockedUsers(username)
+	return formatUserList(fmt.Sprintf("Blocked users (%d):", len(blocked)), blocked)
+}
+
+// --- Chat Rooms ---
+
+// CreateChatRoom allows a user to create a chat room, which they join right away
+func (uc *UserController) CreateChatRoom(username, name string) string {
+	room, err := uc.userService.CreateChatRoom(username, name)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Chat room #" + room.Name + " created. You are its first member."
+}
+
+// JoinChatRoom allows a user to join a chat room and replays its recent messages
+func (uc *UserController) JoinChatRoom(username, name string) string {
+	room, err := uc.userService.JoinChatRoom(username, name)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	response := fmt.Sprintf("Joined #%s (%d members).\n", room.Name, len(room.Members))
+	if len(room.History) > 0 {
+		response += fmt.Sprintf("Last %d messages:\n", len(room.History))
+		for _, message := range room.History {
+			response += fmt.Sprintf("[%s] %s: %s\n", message.SentAt.Format("2006-01-02 15:04"), message.From, message.Text)
+		}
+	}
+	return response
+}
+
+// LeaveChatRoom allows a user to leave a chat room
+func (uc *UserController) LeaveChatRoom(username, name string) string {
+	err := uc.userService.LeaveChatRoom(username, name)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "You left the chat room."
+}
+
+// Say allows a member to post a message to a chat room; the message is echoed back to the sender
+func (uc *UserController) Say(username, name, text string) string {
+	message, err := uc.userService.SendChatMessage(username, name, text)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return fmt.Sprintf("[#%s] %s: %s", message.Room, message.From, message.Text)
+}
+
+// ChatRooms lists every chat room with its member count, marking the ones the user belongs to
+func (uc *UserController) ChatRooms(username string) string {
+	rooms := uc.userService.GetChatRooms()
+	if len(rooms) == 0 {
+		return "No chat rooms yet."
+	}
+	response := fmt.Sprintf("Chat rooms (%d):\n", len(rooms))
+	for _, room := range rooms {
+		response += fmt.Sprintf("- #%s (%d members, created by %s)", room.Name, len(room.Members), room.Owner)
+		if room.HasMember(username) {
+			response += " [joined]"
+		}
+		response += "\n"
+	}
+	return response
+}
+
+// --- Presence ---
+
+// UserConnected records that the user has just logged in
+func (uc *UserController) UserConnected(username string) {
+	uc.userService.RecordLastSeen(username)
+}
+
+// UserDisconnected records that a connection of the user has closed
+func (uc *UserController) UserDisconnected(username string) {
+	uc.userService.RecordLastSeen(username)
+}
+
+// Who lists the users who are online or idle
+func (uc *UserController) Who(viewer string) string {
+	online := uc.userService.WhoIsOnline(viewer)
+	response := fmt.Sprintf("Online users (%d):\n", len(online))
+	for _, presence := range online {
+		response += "- " + formatPresence(presence) + "\n"
+	}
+	return response
+}
+
+// Presence shows whether a user is online, idle or offline and when they were last seen
+func (uc *UserController) Presence(viewer, username string) string {
+	presence, err := uc.userService.GetPresence(viewer, username)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return formatPresence(presence)
+}
+
+// SetPresenceHidden allows a user to hide their presence from others or show it again
+func (uc *UserController) SetPresenceHidden(username string, hidden bool) string {
+	err := uc.userService.SetPresenceHidden(username, hidden)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if hidden {
+		return "You now appear offline to other users."
+	}
+	return "Other users can see when you are online again."
+}
+
+// formatPresence describes a user's presence on one line
+func formatPresence(presence services.Presence) string {
+	switch presence.Status {
+	case "online":
+		return presence.Username + ": online"
+	case "idle":
+		return fmt.Sprintf("%s: idle, last active %s", presence.Username, presence.Since.Format("2006-01-02 15:04"))
+	}
+	if presence.Since.IsZero() {
+		return presence.Username + ": offline"
+	}
+	return fmt.Sprintf("%s: offline, last seen %s", presence.Username, presence.Since.Format("2006-01-02 15:04"))
+}
+
+// --- Discovery ---
+
+// Discover suggests users who share the viewer's interests, those with the most in common first
+func (uc *UserController) Discover(viewer string, page int) string {
+	suggestions, pages, err := uc.userService.Discover(viewer, page)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if page < 1 {
+		page = 1
+	}
+	response := fmt.Sprintf("People you may know (page %d of %d):\n", page, pages)
+	if len(suggestions) == 0 && page == 1 {
+		return response + "No suggestions yet. Fill in your football team, city and favorites to get some.\n"
+	}
+	if len(suggestions) == 0 {
+		return response + "No more suggestions.\n"
+	}
+	for _, suggestion := range suggestions {
+		shared := []string{}
+		for _, field := range suggestion.Shared {
+			shared = append(shared, field.Label)
+		}
+		response += fmt.Sprintf("- %s (same %s)\n", suggestion.Username, strings.Join(shared, ", "))
+	}
+	return response
+}
+
+// ProfileStats allows an admin to see the most common football teams, cities and favorites
+func (uc *UserController) ProfileStats(admin string) string {
+	users, stats, err := uc.userService.GetProfileStats(admin)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	response := fmt.Sprintf("Profile statistics (%d users):\n", users)
+	for _, fieldStats := range stats {
+		response += fmt.Sprintf("%s (filled in by %d):\n", fieldStats.Field.Label, fieldStats.Filled)
+		for _, count := range fieldStats.Top {
+			response += fmt.Sprintf("  %s: %d\n", count.Value, count.Count)
+		}
+	}
+	return response
+}
+
+// --- Search ---
+
+// Search shows one page of blogs and users matching the query, best match first
+func (uc *UserController) Search(viewer, query string, page int) string {
+	results, pages, err := uc.userService.Search(viewer, query, page)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if page < 1 {
+		page = 1
+	}
+	response := fmt.Sprintf("Search results for %s (page %d of %d):\n", query, page, pages)
+	if len(results) == 0 {
+		return response + "No matches.\n"
+	}
+	for _, result := range results {
+		switch result.Kind {
+		case "blog":
+			blog, err := uc.userService.GetBlog(result.ID)
+			if err != nil {
+				continue
+			}
+			response += fmt.Sprintf("[%s] %s by %s (%s)\n", blog.ID, blog.Title, blog.Author, blogDate(blog))
+		case "user":
+			entries, err := uc.userService.GetVisibleProfile(viewer, result.ID)
+			if err != nil {
+				continue
+			}
+			names := []string{}
+			for _, entry := range entries {
+				if (entry.Field.Key == "name" || entry.Field.Key == "surname") && entry.Value != "" {
+					names = append(names, entry.Value)
+				}
+			}
+			response += fmt.Sprintf("User: %s", result.ID)
+			if len(names) > 0 {
+				response += " (" + strings.Join(names, " ") + ")"
+			}
+			response += "\n"
+		}
+	}
+	return response
+}
+
+// --- Reactions ---
+
+// React allows a user to react to a blog post
+func (uc *UserController) React(username, blogID, kind string) string {
+	err := uc.userService.React(username, blogID, kind)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Reaction saved!"
+}
+
+// Unreact allows a user to remove their reaction from a blog post
+func (uc *UserController) Unreact(username, blogID string) string {
+	err := uc.userService.Unreact(username, blogID)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Reaction removed."
+}
+
+// --- Comments ---
+
+// ViewComments shows the comment thread of a blog post, with replies indented under their parent
+func (uc *UserController) ViewComments(viewer, blogID string) string {
+	comments, err := uc.userService.GetComments(viewer, blogID)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if len(comments) == 0 {
+		return "No comments yet."
+	}
+	replies := make(map[string][]models.Comment)
+	for _, comment := range comments {
+		replies[comment.ParentID] = append(replies[comment.ParentID], comment)
+	}
+	response := "Comments:\n"
+	var writeThread func(parentID string, depth int)
+	writeThread = func(parentID string, depth int) {
+		for _, comment := range replies[parentID] {
+			indent := strings.Repeat("  ", depth)
+			if comment.Deleted {
+				response += fmt.Sprintf("%s[%s] [deleted]\n", indent, comment.ID)
+			} else {
+				edited := ""
+				if comment.UpdatedAt.After(comment.CreatedAt) {
+					edited = ", edited"
+				}
+				response += fmt.Sprintf("%s[%s] %s (%s%s): %s\n",
+					indent, comment.ID, comment.Author, comment.CreatedAt.Format("2006-01-02 15:04"), edited, comment.Text)
+			}
+			writeThread(comment.ID, depth+1)
+		}
+	}
+	writeThread("", 0)
+	return response
+}
+
+// AddComment allows a user to comment on a blog post
+func (uc *UserController) AddComment(username, blogID, text string) string {
+	err := uc.userService.AddComment(username, blogID, text)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Comment posted successfully!"
+}
+
+// ReplyToComment allows a user to reply to a comment
+func (uc *UserController) ReplyToComment(username, commentID, text string) string {
+	err := uc.userService.ReplyToComment(username, commentID, text)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Reply posted successfully!"
+}
+
+// EditComment allows a user to edit their own comment
+func (uc *UserController) EditComment(username, commentID, text string) string {
+	err := uc.userService.EditComment(username, commentID, text)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Comment updated successfully!"
+}
+
+// DeleteComment allows a comment's author, the blog's author or an admin to delete a comment
+func (uc *UserController) DeleteComment(username, commentID string) string {
+	err := uc.userService.DeleteComment(username, commentID)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Comment deleted successfully!"
+}
+
+// --- Account ---
+
+// ExportMyData returns everything stored about the user as a JSON document
+func (uc *UserController) ExportMyData(username string) string {
+	data, err := uc.userService.ExportUserData(username)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return string(data) + "\n"
+}
+
+// DeleteAccount schedules the user's account for deletion after confirming their password
+func (uc *UserController) DeleteAccount(username, password string) string {
+	deleteAfter, err := uc.userService.RequestAccountDeletion(username, password)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return fmt.Sprintf("Your account will be deleted on %s. Log in and use cancel-deletion before then to keep it.", deleteAfter.Format("2006-01-02 15:04"))
+}
+
+// CancelDeletion keeps an account whose deletion was requested
+func (uc *UserController) CancelDeletion(username string) string {
+	err := uc.userService.CancelAccountDeletion(username)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Your account will no longer be deleted."
+}
+
+// DeletionNotice reminds a user who logs in that their account is scheduled for deletion; it is empty otherwise
+func (uc *UserController) DeletionNotice(username string) string {
+	deleteAfter := uc.userService.GetScheduledDeletion(username)
+	if deleteAfter.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("Your account is scheduled for deletion on %s. Use cancel-deletion to keep it.\n", deleteAfter.Format("2006-01-02 15:04"))
+}
+
+// PurgeDeletedAccounts deletes every account whose grace period has ended and returns their usernames
+func (uc *UserController) PurgeDeletedAccounts() []string {
+	return uc.userService.PurgeDueAccounts(time.Now())
+}
+
+// --- Invites ---
+
+// CreateInvite creates an invite code for up to maxUses people, valid for validFor or the configured validity if zero
+func (uc *UserController) CreateInvite(username string, maxUses int, validFor time.Duration) string {
+	invite, err := uc.userService.CreateInvite(username, maxUses, validFor)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return fmt.Sprintf("Invite code %s created for %d people, valid until %s.\nNew users register with: reg <username> <password> %s",
+		invite.Code, invite.MaxUses, invite.ExpiresAt.Format("2006-01-02 15:04"), invite.Code)
+}
+
+// MyInvites lists the invite codes the user created and who registered with them
+func (uc *UserController) MyInvites(username string) string {
+	invites := uc.userService.GetMyInvites(username)
+	response := "Your invite codes:\n"
+	if !uc.userService.IsAdmin(username) {
+		response = fmt.Sprintf("Your invite codes (%d invitations left):\n", uc.userService.RemainingInvites(username))
+	}
+	if len(invites) == 0 {
+		return response + "No invite codes yet.\n"
+	}
+	for _, invite := range invites {
+		response += formatInvite(invite, false)
+	}
+	return response
+}
+
+// Invites allows an admin to see one page of every invite code
+func (uc *UserController) Invites(admin string, page int) string {
+	invites, pages, err := uc.userService.GetAllInvites(admin, page)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if page < 1 {
+		page = 1
+	}
+	response := fmt.Sprintf("Invite codes (page %d of %d):\n", page, pages)
+	if len(invites) == 0 {
+		return response + "No invite codes.\n"
+	}
+	for _, invite := range invites {
+		response += formatInvite(invite, true)
+	}
+	return response
+}
+
+// RevokeInvite allows the creator of an invite code, or an admin, to stop it from being used again
+func (uc *UserController) RevokeInvite(username, code string) string {
+	err := uc.userService.RevokeInvite(username, strings.ToUpper(code))
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Invite code revoked. Accounts already registered with it are kept."
+}
+
+// InviteTree allows an admin to trace who invited whom, starting from a user or from everyone
+func (uc *UserController) InviteTree(admin, username string) string {
+	nodes, err := uc.userService.GetInviteTree(admin, username)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if len(nodes) == 0 {
+		return "Nobody has registered with an invite code yet.\n"
+	}
+	response := "Invite tree:\n"
+	for _, node := range nodes {
+		response += strings.Repeat("  ", node.Depth) + "- " + node.Username
+		if node.Code != "" {
+			response += " (code " + node.Code
+			if node.Depth == 0 && node.InvitedBy != "" {
+				response += ", invited by " + node.InvitedBy
+			}
+			response += ")"
+		}
+		response += "\n"
+	}
+	return response
+}
+
+// formatInvite describes an invite code on one line, followed by who registered with it
+func formatInvite(invite models.InviteCode, showCreator bool) string {
+	line := "- " + invite.Code
+	if showCreator {
+		line += " by " + invite.CreatedBy
+	}
+	line += fmt.Sprintf(": used %d/%d", len(invite.UsedBy), invite.MaxUses)
+	switch {
+	case !invite.RevokedAt.IsZero():
+		line += fmt.Sprintf(", revoked by %s on %s", invite.RevokedBy, invite.RevokedAt.Format("2006-01-02 15:04"))
+	case !time.Now().Before(invite.ExpiresAt):
+		line += ", expired " + invite.ExpiresAt.Format("2006-01-02 15:04")
+	default:
+		line += ", expires " + invite.ExpiresAt.Format("2006-01-02 15:04")
+	}
+	line += "\n"
+	if len(invite.UsedBy) > 0 {
+		line += "  registered: " + strings.Join(invite.UsedBy, ", ") + "\n"
+	}
+	return line
+}
+
 // --- Admin Management ---
 
 // ViewUsers allows an admin to view all registered users
@@ -98,28 +1062,129 @@ func (uc *UserController) DeleteUser(username string) string {
 	return "User deleted successfully!"
 }
 
+// UsernameReport allows an admin to see look-alike usernames and usernames that break the current rules
+func (uc *UserController) UsernameReport(admin string) string {
+	collisions, issues, err := uc.userService.GetUsernameReport(admin)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	response := fmt.Sprintf("Username collisions (%d):\n", len(collisions))
+	for _, collision := range collisions {
+		response += fmt.Sprintf("- %s: accounts %s", collision.Canonical, strings.Join(collision.Accounts, ", "))
+		if len(collision.Authors) > 0 {
+			response += fmt.Sprintf("; authors without an account %s", strings.Join(collision.Authors, ", "))
+		}
+		response += "\n"
+	}
+	response += fmt.Sprintf("Usernames breaking the current rules (%d):\n", len(issues))
+	for _, issue := range issues {
+		response += fmt.Sprintf("- %s: %s\n", issue.Username, issue.Problem)
+	}
+	return response
+}
+
+// PendingRegistrations allows an admin to see the accounts waiting for approval
+func (uc *UserController) PendingRegistrations(admin string) string {
+	users, err := uc.userService.GetPendingRegistrations(admin)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	response := fmt.Sprintf("Pending Registrations (%d):\n", len(users))
+	for _, user := range users {
+		response += fmt.Sprintf("- %s (registered %s)\n", user.Username, user.RegisteredAt.Format("2006-01-02 15:04"))
+	}
+	return response
+}
+
+// ApproveRegistration allows an admin to let a pending account log in
+func (uc *UserController) ApproveRegistration(admin, username string) string {
+	err := uc.userService.ApproveRegistration(admin, username)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Registration approved for user: " + username
+}
+
+// DenyRegistration allows an admin to turn down a pending account, which deletes it
+func (uc *UserController) DenyRegistration(admin, username, reason string) string {
+	err := uc.userService.DenyRegistration(admin, username, reason)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return "Registration denied for user: " + username
+}
+
 // ViewPendingApprovals allows an admin to see pending admin applications
 func (uc *UserController) ViewPendingApprovals() string {
-	users := uc.userService.GetPendingAdminApprovals()
+	applications := uc.userService.GetPendingAdminApprovals()
 	response := "Pending Admin Approvals:\n"
-	for _, user := range users {
-		response += fmt.Sprintf("- %s\n", user.Username)
+	for _, application := range applications {
+		response += fmt.Sprintf("- %s (applied %s, %d approvals): %s\n",
+			application.Applicant, application.CreatedAt.Format("2006-01-02 15:04"), len(application.Approvals), application.Motivation)
+	}
+	demotions := uc.userService.GetPendingDemotions()
+	if len(demotions) > 0 {
+		response += "Pending Demotions:\n"
+		for _, demotion := range demotions {
+			response += fmt.Sprintf("- %s (requested by %s, %d votes): %s\n",
+				demotion.Target, demotion.RequestedBy, len(demotion.Approvals), demotion.Reason)
+		}
 	}
 	return response
 }
 
+// DemoteAdmin allows an admin to vote for removing the admin role from another admin
+func (uc *UserController) DemoteAdmin(target, requestedBy, reason string) string {
+	votes, required, err := uc.userService.DemoteAdmin(target, requestedBy, reason)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if votes < required {
+		return fmt.Sprintf("Demotion vote recorded for user: %s (%d/%d votes)", target, votes, required)
+	}
+	return "Admin rights removed from user: " + target
+}
+
+// ViewAuditLog allows an admin to see one page of the audit log, newest first
+func (uc *UserController) ViewAuditLog(viewer string, page int) string {
+	entries, pages, err := uc.userService.GetAuditLog(viewer, page)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if page < 1 {
+		page = 1
+	}
+	response := fmt.Sprintf("Audit Log (page %d of %d):\n", page, pages)
+	if len(entries) == 0 {
+		return response + "No entries.\n"
+	}
+	for _, entry := range entries {
+		response += fmt.Sprintf("- %s %s %s %s: %s\n",
+			entry.Time.Format("2006-01-02 15:04:05"), entry.Actor, entry.Action, entry.Target, entry.Detail)
+	}
+	return response
+}
+
+// IsAdmin reports whether the user currently holds the admin role
+func (uc *UserController) IsAdmin(username string) bool {
+	return uc.userService.IsAdmin(username)
+}
+
 // ApproveAdmin allows an admin to approve a user's admin request
-func (uc *UserController) ApproveAdmin(username string) string {
-	err := uc.userService.ApproveAdminRequest(username)
+func (uc *UserController) ApproveAdmin(username, decidedBy, reason string) string {
+	approvals, required, err := uc.userService.ApproveAdminRequest(username, decidedBy, reason)
 	if err != nil {
 		return "Error: " + err.Error()
 	}
+	if approvals < required {
+		return fmt.Sprintf("Approval recorded for user: %s (%d/%d approvals)", username, approvals, required)
+	}
 	return "Admin request approved for user: " + username
 }
 
 // RejectAdmin allows an admin to reject a user's admin request
-func (uc *UserController) RejectAdmin(username string) string {
-	err := uc.userService.RejectAdminRequest(username)
+func (uc *UserController) RejectAdmin(username, decidedBy, reason string) string {
+	err := uc.userService.RejectAdminRequest(username, decidedBy, reason)
 	if err != nil {
 		return "Error: " + err.Error()
 	}
@@ -127,14 +1192,34 @@ func (uc *UserController) RejectAdmin(username string) string {
 }
 
 // ApplyForAdmin allows a user to apply for admin status
-func (uc *UserController) ApplyForAdmin(username string) string {
-	err := uc.userService.ApplyForAdmin(username)
+func (uc *UserController) ApplyForAdmin(username, motivation string) string {
+	err := uc.userService.ApplyForAdmin(username, motivation)
 	if err != nil {
 		return "Error: " + err.Error()
 	}
 	return "Admin application submitted successfully."
 }
 
+// MyApplications allows a user to see the status of their admin applications
+func (uc *UserController) MyApplications(username string) string {
+	applications := uc.userService.GetAdminApplications(username)
+	if len(applications) == 0 {
+		return "You have not applied for admin."
+	}
+	response := "Your Admin Applications:\n"
+	for _, application := range applications {
+		response += fmt.Sprintf("- %s: %s", application.CreatedAt.Format("2006-01-02 15:04"), application.Decision)
+		if application.Decision != "pending" {
+			response += fmt.Sprintf(" by %s", application.DecidedBy)
+			if application.Reason != "" {
+				response += fmt.Sprintf(" (%s)", application.Reason)
+			}
+		}
+		response += "\n"
+	}
+	return response
+}
+
 func (s *UserController) GetBlogsByUser(username string) []models.Blog {
 	return s.userService.GetBlogsByUser(username)
 }
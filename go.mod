@@ -5,4 +5,7 @@ go 1.23.2
 require (
 	github.com/mattn/go-sqlite3 v1.14.24
 	golang.org/x/crypto v0.28.0
+	golang.org/x/text v0.19.0
 )
+
+require golang.org/x/sys v0.26.0 // indirect
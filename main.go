@@ -2,6 +2,8 @@ package main
 
 import (
 	"bufio"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net"
@@ -9,6 +11,7 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 
 	"go-socket-server/controllers"
 	"go-socket-server/models"
@@ -20,11 +23,60 @@ var (
 	mu             sync.Mutex
 )
 
+// schedulerInterval is how often the server checks for scheduled blogs that are due
+var schedulerInterval = 30 * time.Second
+
 func main() {
+	config := services.DefaultConfig()
+	flag.IntVar(&config.AdminQuorum, "admin-quorum", config.AdminQuorum, "number of distinct admins required to promote or demote an admin")
+	flag.IntVar(&config.MaxBlogBytes, "max-blog-size", config.MaxBlogBytes, "maximum size of a blog body in bytes")
+	flag.IntVar(&config.MaxCommentBytes, "max-comment-size", config.MaxCommentBytes, "maximum size of a comment in bytes")
+	flag.IntVar(&config.MaxMessageBytes, "max-message-size", config.MaxMessageBytes, "maximum size of a direct or chat room message in bytes")
+	flag.IntVar(&config.ChatScrollback, "chat-scrollback", config.ChatScrollback, "number of recent chat room messages replayed when joining a room")
+	flag.DurationVar(&config.IdleAfter, "idle-after", config.IdleAfter, "how long a connected user may stay silent before they are shown as idle")
+	flag.IntVar(&config.PageSize, "page-size", config.PageSize, "number of items shown per page in listings")
+	flag.DurationVar(&schedulerInterval, "scheduler-interval", schedulerInterval, "how often scheduled blogs are checked for publishing")
+	flag.IntVar(&config.UsernameMinLength, "username-min-length", config.UsernameMinLength, "minimum length of a new username")
+	flag.IntVar(&config.UsernameMaxLength, "username-max-length", config.UsernameMaxLength, "maximum length of a new username")
+	flag.StringVar(&config.UsernameCharset, "username-charset", config.UsernameCharset, "characters allowed in a lowercased username, as a regexp character class without the brackets")
+	reservedUsernames := flag.String("reserved-usernames", strings.Join(config.ReservedUsernames, ","), "comma-separated list of usernames nobody may register")
+	flag.DurationVar(&config.DeletionGracePeriod, "deletion-grace", config.DeletionGracePeriod, "how long a deleted account can still be restored before it is removed for good")
+	flag.StringVar(&config.DeletedContent, "deleted-content", config.DeletedContent, "what happens to the blogs and comments of a deleted account: remove or anonymize")
+	flag.StringVar(&config.RegistrationMode, "registration-mode", config.RegistrationMode, "who may create an account: "+strings.Join(services.RegistrationModes, ", "))
+	flag.IntVar(&config.InviteQuota, "invite-quota", config.InviteQuota, "how many people a regular user may invite; 0 leaves invite codes to admins")
+	flag.DurationVar(&config.InviteValidity, "invite-validity", config.InviteValidity, "how long a new invite code can be used")
+	flag.StringVar(&config.PasswordHash, "password-hash", config.PasswordHash, "algorithm for new password hashes: "+strings.Join(services.PasswordHashes, ", "))
+	flag.IntVar(&config.BcryptCost, "bcrypt-cost", config.BcryptCost, "bcrypt work factor; stored hashes with a lower cost are upgraded at login")
+	flag.IntVar(&config.Argon2Time, "argon2-time", config.Argon2Time, "argon2id number of passes")
+	flag.IntVar(&config.Argon2MemoryKiB, "argon2-memory", config.Argon2MemoryKiB, "argon2id memory use in KiB")
+	flag.IntVar(&config.Argon2Threads, "argon2-threads", config.Argon2Threads, "argon2id parallelism")
+	bootstrapAdmin := flag.String("bootstrap-admin", "", "create this user as the first admin if no admin exists yet")
+	bootstrapPassword := flag.String("bootstrap-password", "", "password for the user created with -bootstrap-admin")
+	flag.Parse()
+	config.ReservedUsernames = strings.Split(*reservedUsernames, ",")
+
 	// Initialize the in-memory user repository and services
 	userRepo := models.NewInMemoryUserRepository("users.json")
-	userService := services.NewUserService(userRepo)
+	userService := services.NewUserService(userRepo, config)
 	userController := controllers.NewUserController(userService)
+	userService.SetNotifier(sessionTracker{})
+	userService.SetSessionTracker(sessionTracker{})
+
+	// Create the first admin account when requested on the command line
+	if *bootstrapAdmin != "" {
+		if *bootstrapPassword == "" {
+			log.Fatal("-bootstrap-password is required with -bootstrap-admin")
+		}
+		// The flag is usually left in place across restarts, so an existing admin is not an error
+		err := userService.BootstrapAdmin(*bootstrapAdmin, *bootstrapPassword)
+		if errors.Is(err, services.ErrAdminExists) {
+			log.Printf("An admin already exists, not creating %s.", *bootstrapAdmin)
+		} else if err != nil {
+			log.Fatalf("Could not create bootstrap admin: %s", err)
+		} else {
+			fmt.Printf("Created admin account %s.\n", *bootstrapAdmin)
+		}
+	}
 
 	// Start the server
 	startServer(userController)
@@ -39,6 +91,19 @@ func startServer(controller *controllers.UserController) {
 
 	fmt.Println("Server is listening on port 8080...")
 
+	// Routine to publish scheduled blogs and delete accounts once their time has come
+	go func() {
+		for {
+			for _, blog := range controller.PublishDueBlogs() {
+				log.Printf("Published scheduled blog %s by %s", blog.ID, blog.Author)
+			}
+			for _, username := range controller.PurgeDeletedAccounts() {
+				log.Printf("Deleted account %s", username)
+			}
+			time.Sleep(schedulerInterval)
+		}
+	}()
+
 	// Routine to display the count of connected users every 10 seconds
 	go func() {
 		for {
@@ -76,15 +141,22 @@ func handleConnection(conn net.Conn, controller *controllers.UserController) {
 		conn.Close()
 	}()
 
-	reader := bufio.NewReader(conn)
-	writer := bufio.NewWriter(conn)
+	writer := newSessionWriter(conn)
+	reader := bufio.NewReader(sessionInput{conn: conn, writer: writer})
 
 	var loggedInUser string
 	var isAdmin bool
 
+	defer func() {
+		if loggedInUser != "" {
+			unregisterSession(loggedInUser, writer)
+			controller.UserDisconnected(loggedInUser)
+		}
+	}()
+
 	// Display welcome message and prompt for login or registration
 	writer.WriteString("******Welcome to the Go Socket Server!******\n")
-	writer.WriteString("Type 'reg <username> <password>' to register.\n")
+	writer.WriteString("Type 'reg <username> <password> [invite-code]' to register.\n")
 	writer.WriteString("Type 'log <username> <password>' to log in.\n")
 	writer.Flush()
 
@@ -110,12 +182,16 @@ func handleConnection(conn net.Conn, controller *controllers.UserController) {
 		switch cmd {
 		case "reg":
 			// Registration is allowed without login
-			if len(commandParts) != 3 {
-				response = "Usage: reg <username> <password>\n"
+			if len(commandParts) != 3 && len(commandParts) != 4 {
+				response = "Usage: reg <username> <password> [invite-code]\n"
 			} else {
 				username := commandParts[1]
 				password := commandParts[2]
-				response = controller.Register(username, password, "user", "pending")
+				inviteCode := ""
+				if len(commandParts) == 4 {
+					inviteCode = commandParts[3]
+				}
+				response = controller.Register(username, password, inviteCode)
 			}
 
 		case "log":
@@ -126,17 +202,23 @@ func handleConnection(conn net.Conn, controller *controllers.UserController) {
 				username := commandParts[1]
 				password := commandParts[2]
 				loggedInUser, isAdmin, err = controller.Login(username, password)
-				if err != nil {
+				if errors.Is(err, services.ErrRegistrationPending) {
+					response = "Your account is waiting for an admin to approve it. Please try again later.\n"
+				} else if err != nil {
 					response = "Invalid username or password. Please try again.\n"
 				} else {
 					if isAdmin {
-						writer.WriteString("Login successful. Welcome Admin, " + username + "!\n")
+						writer.WriteString("Login successful. Welcome Admin, " + loggedInUser + "!\n")
 					} else {
-						writer.WriteString("Login successful. Welcome, " + username + "!\n")
+						writer.WriteString("Login successful. Welcome, " + loggedInUser + "!\n")
 					}
 					response = displayMenu(isAdmin)
 					writer.WriteString(response + "\n")
+					writer.WriteString(controller.DeletionNotice(loggedInUser))
+					writer.WriteString(controller.QueuedMessages(loggedInUser))
 					writer.Flush()
+					registerSession(loggedInUser, writer)
+					controller.UserConnected(loggedInUser)
 
 					// Allow user to perform other actions after login
 					for {
@@ -155,6 +237,9 @@ func handleConnection(conn net.Conn, controller *controllers.UserController) {
 						}
 						cmd = commandParts[0]
 
+						// Role changes made by other admins take effect on the next command
+						isAdmin = controller.IsAdmin(loggedInUser)
+
 						switch cmd {
 						// --- Profile Management ---
 						case "view-profile":
@@ -166,44 +251,36 @@ func handleConnection(conn net.Conn, controller *controllers.UserController) {
 							editResponse = strings.TrimSpace(editResponse)
 
 							if editResponse == "yes" {
-								// Prompt for profile update fields one by one
-								writer.WriteString("Name: ")
-								writer.Flush()
-								name, _ := reader.ReadString('\n')
-								name = strings.TrimSpace(name)
-
-								writer.WriteString("Surname: ")
-								writer.Flush()
-								surname, _ := reader.ReadString('\n')
-								surname = strings.TrimSpace(surname)
-
-								writer.WriteString("Favorite Animal: ")
-								writer.Flush()
-								favAnimal, _ := reader.ReadString('\n')
-								favAnimal = strings.TrimSpace(favAnimal)
-
-								writer.WriteString("Favorite Movie: ")
-								writer.Flush()
-								favMovie, _ := reader.ReadString('\n')
-								favMovie = strings.TrimSpace(favMovie)
-
-								writer.WriteString("Year of Birth: ")
-								writer.Flush()
-								yearOfBirth, _ := reader.ReadString('\n')
-								yearOfBirth = strings.TrimSpace(yearOfBirth)
-
-								writer.WriteString("City of Birth: ")
-								writer.Flush()
-								city, _ := reader.ReadString('\n')
-								city = strings.TrimSpace(city)
-
-								writer.WriteString("Football Team: ")
-								writer.Flush()
-								footballTeam, _ := reader.ReadString('\n')
-								footballTeam = strings.TrimSpace(footballTeam)
+								// Prompt for profile update fields one by one, keeping the current value on empty input
+								user, err := controller.GetUser(loggedInUser)
+								if err != nil {
+									response = "Error: " + err.Error()
+									break
+								}
+								writer.WriteString("Press enter to keep the current value, or enter \"-\" to clear a field.\n")
+								changes := make(map[string]string)
+								for _, field := range controller.GetProfileSchema() {
+									label := field.Label
+									if field.Required {
+										label += " (required)"
+									}
+									value := prompt(reader, writer, fmt.Sprintf("%s [%s]: ", label, user.ProfileField(field.Key)))
+									// A required field that is still empty must be answered; give up after a few tries
+									missing := field.Required && user.ProfileField(field.Key) == ""
+									for tries := 0; missing && (value == "" || value == "-") && tries < maxRequiredPrompts; tries++ {
+										value = prompt(reader, writer, fmt.Sprintf("%s is required. %s: ", field.Label, field.Label))
+									}
+									switch {
+									case value == "-", value == "" && missing:
+										// A required field left empty is reported by the update
+										changes[field.Key] = ""
+									case value != "":
+										changes[field.Key] = value
+									}
+								}
 
-								// Pass the collected information to update the profile
-								text := controller.UpdateProfile(loggedInUser, name, surname, favAnimal, favMovie, yearOfBirth, city, footballTeam)
+								// Pass only the changed fields to update the profile
+								text := controller.UpdateProfile(loggedInUser, changes)
 								writer.WriteString(text + "\n")
 								response = displayMenu(isAdmin)
 								break
@@ -213,14 +290,53 @@ func handleConnection(conn net.Conn, controller *controllers.UserController) {
 								break
 							}
 
+						case "set-profile":
+							if len(commandParts) < 2 {
+								response = "Usage: set-profile <field> [value]\nFields: " + profileFieldKeys(controller) + "\n"
+							} else {
+								response = controller.SetProfileField(loggedInUser, commandParts[1], argumentText(command, 2))
+							}
+
+						case "profile":
+							if len(commandParts) != 2 {
+								response = "Usage: profile <username>\n"
+							} else {
+								response = controller.Profile(loggedInUser, commandParts[1])
+							}
+						case "profile-visibility":
+							if len(commandParts) != 3 {
+								response = "Usage: profile-visibility <field> <public|followers|private>\n"
+							} else {
+								response = controller.SetProfileVisibility(loggedInUser, commandParts[1], commandParts[2])
+							}
+						case "discover":
+							page, ok := parsePage(commandParts, 1)
+							if !ok {
+								response = "Usage: discover [page]\n"
+							} else {
+								response = controller.Discover(loggedInUser, page)
+							}
+						case "profile-fields":
+							response = controller.ProfileFields()
+
 						// --- Blog Management ---
 						case "my-blogs":
 							response = "Your Blogs:\n"
 							blogs := controller.GetBlogsByUser(loggedInUser)
 							for i, blog := range blogs {
-								response += fmt.Sprintf("%d. %s\n%s\n", i+1, blog.Title, blog.Text)
+								status := ""
+								switch blog.Status {
+								case "draft":
+									status = " [draft]"
+								case "scheduled":
+									status = " [scheduled for " + blog.PublishAt.Format(publishTimeLayout) + "]"
+								}
+								if blog.Visibility != "public" {
+									status += " [" + blog.Visibility + "]"
+								}
+								response += fmt.Sprintf("%d. %s%s\n%s\n", i+1, blog.Title, status, blog.Text)
 							}
-							writer.WriteString(response + "\nWould you like to post a new blog or delete one? (post/delete/exit): ")
+							writer.WriteString(response + "\nWould you like to post, edit or delete a blog, or browse its revisions? (post/edit/tag/visibility/publish/schedule/unschedule/revisions/diff/restore/delete/exit): ")
 							writer.Flush()
 
 							actionResponse, _ := reader.ReadString('\n')
@@ -233,12 +349,26 @@ func handleConnection(conn net.Conn, controller *controllers.UserController) {
 								title, _ := reader.ReadString('\n')
 								title = strings.TrimSpace(title)
 
-								writer.WriteString("Blog Text: ")
-								writer.Flush()
-								text, _ := reader.ReadString('\n')
-								text = strings.TrimSpace(text)
+								text, err := promptMultiline(reader, writer, "Blog Text", controller.MaxBlogBytes())
+								if err != nil {
+									response = "Error: " + err.Error()
+									break
+								}
+
+								tags := parseTags(prompt(reader, writer, "Tags (separated by spaces or commas, optional): "))
+
+								status, publishAt, ok := promptPublishing(reader, writer)
+								if !ok {
+									response = "Invalid option. The blog was not saved.\nReturning to main menu.\n"
+									break
+								}
+
+								visibility := prompt(reader, writer, "Who can read it? (public/followers/private, default public): ")
+								if visibility == "" {
+									visibility = "public"
+								}
 
-								response = controller.PostBlog(loggedInUser, title, text)
+								response = controller.PostBlog(loggedInUser, title, text, tags, status, visibility, publishAt)
 								displayMenu(isAdmin)
 							case "delete":
 								if len(blogs) == 0 {
@@ -257,6 +387,105 @@ func handleConnection(conn net.Conn, controller *controllers.UserController) {
 										response = controller.DeleteBlog(loggedInUser, blogID)
 									}
 								}
+							case "edit":
+								blog, ok := promptBlog(reader, writer, blogs, "edit")
+								if !ok {
+									response = "Invalid blog number.\nReturning to main menu.\n"
+									break
+								}
+								title := prompt(reader, writer, "New Title (leave empty to keep current): ")
+								if title == "" {
+									title = blog.Title
+								}
+								text, err := promptMultiline(reader, writer, "New Text (leave empty to keep current)", controller.MaxBlogBytes())
+								if err != nil {
+									response = "Error: " + err.Error()
+									break
+								}
+								if text == "" {
+									text = blog.Text
+								}
+								response = controller.EditBlog(loggedInUser, blog.ID, title, text)
+							case "tag":
+								blog, ok := promptBlog(reader, writer, blogs, "tag")
+								if !ok {
+									response = "Invalid blog number.\nReturning to main menu.\n"
+									break
+								}
+								if len(blog.Tags) > 0 {
+									writer.WriteString("Current tags: #" + strings.Join(blog.Tags, " #") + "\n")
+								}
+								tags := parseTags(prompt(reader, writer, "New Tags (separated by spaces or commas, empty to clear): "))
+								response = controller.SetTags(loggedInUser, blog.ID, tags)
+							case "visibility":
+								blog, ok := promptBlog(reader, writer, blogs, "change visibility of")
+								if !ok {
+									response = "Invalid blog number.\nReturning to main menu.\n"
+									break
+								}
+								writer.WriteString("Currently visible to: " + blog.Visibility + "\n")
+								visibility := prompt(reader, writer, "Who can read it? (public/followers/private): ")
+								response = controller.SetVisibility(loggedInUser, blog.ID, visibility)
+							case "publish":
+								blog, ok := promptBlog(reader, writer, blogs, "publish")
+								if !ok {
+									response = "Invalid blog number.\nReturning to main menu.\n"
+									break
+								}
+								response = controller.PublishBlog(loggedInUser, blog.ID)
+							case "schedule":
+								blog, ok := promptBlog(reader, writer, blogs, "schedule")
+								if !ok {
+									response = "Invalid blog number.\nReturning to main menu.\n"
+									break
+								}
+								publishAt, err := time.ParseInLocation(publishTimeLayout, prompt(reader, writer, "Publish at (YYYY-MM-DD HH:MM): "), time.Local)
+								if err != nil {
+									response = "Invalid time.\nReturning to main menu.\n"
+									break
+								}
+								response = controller.ScheduleBlog(loggedInUser, blog.ID, publishAt)
+							case "unschedule":
+								blog, ok := promptBlog(reader, writer, blogs, "move back to drafts")
+								if !ok {
+									response = "Invalid blog number.\nReturning to main menu.\n"
+									break
+								}
+								response = controller.UnscheduleBlog(loggedInUser, blog.ID)
+							case "revisions":
+								blog, ok := promptBlog(reader, writer, blogs, "inspect")
+								if !ok {
+									response = "Invalid blog number.\nReturning to main menu.\n"
+									break
+								}
+								response = controller.ListRevisions(blog.ID)
+							case "diff":
+								blog, ok := promptBlog(reader, writer, blogs, "compare")
+								if !ok {
+									response = "Invalid blog number.\nReturning to main menu.\n"
+									break
+								}
+								writer.WriteString(controller.ListRevisions(blog.ID))
+								from, errFrom := strconv.Atoi(prompt(reader, writer, "Compare from revision: "))
+								to, errTo := strconv.Atoi(prompt(reader, writer, "Compare to revision: "))
+								if errFrom != nil || errTo != nil {
+									response = "Invalid revision number.\nReturning to main menu.\n"
+									break
+								}
+								response = controller.DiffRevisions(blog.ID, from, to)
+							case "restore":
+								blog, ok := promptBlog(reader, writer, blogs, "restore")
+								if !ok {
+									response = "Invalid blog number.\nReturning to main menu.\n"
+									break
+								}
+								writer.WriteString(controller.ListRevisions(blog.ID))
+								revision, err := strconv.Atoi(prompt(reader, writer, "Revision to restore: "))
+								if err != nil {
+									response = "Invalid revision number.\nReturning to main menu.\n"
+									break
+								}
+								response = controller.RestoreRevision(loggedInUser, blog.ID, revision)
 							case "exit":
 								response = displayMenu(isAdmin)
 								break
@@ -264,9 +493,282 @@ func handleConnection(conn net.Conn, controller *controllers.UserController) {
 								response = "Invalid option. Returning to main menu."
 							}
 
+						// --- Reading Blogs ---
+						case "feed":
+							page, ok := parsePage(commandParts, 1)
+							if !ok {
+								response = "Usage: feed [page]\n"
+							} else {
+								response = controller.Feed(loggedInUser, page)
+							}
+						case "blogs":
+							page, ok := parsePage(commandParts, 2)
+							if len(commandParts) < 2 || !ok {
+								response = "Usage: blogs <username> [page]\n"
+							} else {
+								response = controller.UserPosts(loggedInUser, commandParts[1], page)
+							}
+						case "read":
+							if len(commandParts) != 2 {
+								response = "Usage: read <blog-id>\n"
+							} else {
+								response = controller.ReadPost(loggedInUser, commandParts[1])
+							}
+
+						case "tags":
+							response = controller.PopularTags(loggedInUser)
+						case "tagged":
+							page, ok := parsePage(commandParts, 2)
+							if len(commandParts) < 2 || !ok {
+								response = "Usage: tagged <tag> [page]\n"
+							} else {
+								response = controller.TaggedPosts(loggedInUser, commandParts[1], page)
+							}
+
+						// --- Following ---
+						case "follow":
+							if len(commandParts) != 2 {
+								response = "Usage: follow <username>\n"
+							} else {
+								response = controller.Follow(loggedInUser, commandParts[1])
+							}
+						case "unfollow":
+							if len(commandParts) != 2 {
+								response = "Usage: unfollow <username>\n"
+							} else {
+								response = controller.Unfollow(loggedInUser, commandParts[1])
+							}
+						case "followers", "following":
+							username := loggedInUser
+							if len(commandParts) == 2 {
+								username = commandParts[1]
+							}
+							if len(commandParts) > 2 {
+								response = "Usage: " + cmd + " [username]\n"
+							} else if cmd == "followers" {
+								response = controller.Followers(username)
+							} else {
+								response = controller.Following(username)
+							}
+						case "timeline":
+							page, ok := parsePage(commandParts, 1)
+							if !ok {
+								response = "Usage: timeline [page]\n"
+							} else {
+								response = controller.Timeline(loggedInUser, page)
+							}
+
+						// --- Direct Messages ---
+						case "send":
+							if len(commandParts) < 3 {
+								response = "Usage: send <username> <message>\n"
+							} else {
+								response = controller.SendMessage(loggedInUser, commandParts[1], argumentText(command, 2))
+							}
+						case "inbox":
+							response = controller.Inbox(loggedInUser)
+						case "conversation":
+							page, ok := parsePage(commandParts, 2)
+							if len(commandParts) < 2 || !ok {
+								response = "Usage: conversation <username> [page]\n"
+							} else {
+								response = controller.Conversation(loggedInUser, commandParts[1], page)
+							}
+						case "block":
+							if len(commandParts) != 2 {
+								response = "Usage: block <username>\n"
+							} else {
+								response = controller.BlockUser(loggedInUser, commandParts[1])
+							}
+						case "unblock":
+							if len(commandParts) != 2 {
+								response = "Usage: unblock <username>\n"
+							} else {
+								response = controller.UnblockUser(loggedInUser, commandParts[1])
+							}
+						case "blocked":
+							response = controller.BlockedUsers(loggedInUser)
+
+						// --- Presence ---
+						case "who":
+							response = controller.Who(loggedInUser)
+						case "presence":
+							if len(commandParts) != 2 {
+								response = "Usage: presence <username>\n"
+							} else {
+								response = controller.Presence(loggedInUser, commandParts[1])
+							}
+						case "hide-presence":
+							response = controller.SetPresenceHidden(loggedInUser, true)
+						case "show-presence":
+							response = controller.SetPresenceHidden(loggedInUser, false)
+
+						// --- Account ---
+						case "export-my-data":
+							response = controller.ExportMyData(loggedInUser)
+						case "delete-account":
+							password := prompt(reader, writer, "Confirm your password: ")
+							response = controller.DeleteAccount(loggedInUser, password)
+						case "cancel-deletion":
+							response = controller.CancelDeletion(loggedInUser)
+
+						// --- Invites ---
+						case "create-invite":
+							maxUses, validDays := 1, 0
+							var errUses, errDays error
+							if len(commandParts) > 1 {
+								maxUses, errUses = strconv.Atoi(commandParts[1])
+							}
+							if len(commandParts) > 2 {
+								validDays, errDays = strconv.Atoi(commandParts[2])
+							}
+							if len(commandParts) > 3 || errUses != nil || errDays != nil || validDays < 0 {
+								response = "Usage: create-invite [uses] [days]\n"
+							} else {
+								response = controller.CreateInvite(loggedInUser, maxUses, time.Duration(validDays)*24*time.Hour)
+							}
+						case "my-invites":
+							response = controller.MyInvites(loggedInUser)
+						case "revoke-invite":
+							if len(commandParts) != 2 {
+								response = "Usage: revoke-invite <code>\n"
+							} else {
+								response = controller.RevokeInvite(loggedInUser, commandParts[1])
+							}
+						case "invites":
+							page, ok := parsePage(commandParts, 1)
+							if !isAdmin {
+								response = "You do not have permission to perform this action.\nReturning to main menu.\n"
+							} else if !ok {
+								response = "Usage: invites [page]\n"
+							} else {
+								response = controller.Invites(loggedInUser, page)
+							}
+						case "invite-tree":
+							if !isAdmin {
+								response = "You do not have permission to perform this action.\nReturning to main menu.\n"
+							} else if len(commandParts) > 2 {
+								response = "Usage: invite-tree [username]\n"
+							} else {
+								username := ""
+								if len(commandParts) == 2 {
+									username = commandParts[1]
+								}
+								response = controller.InviteTree(loggedInUser, username)
+							}
+
+						// --- Chat Rooms ---
+						case "rooms":
+							response = controller.ChatRooms(loggedInUser)
+						case "create-room":
+							if len(commandParts) != 2 {
+								response = "Usage: create-room <name>\n"
+							} else {
+								response = controller.CreateChatRoom(loggedInUser, commandParts[1])
+							}
+						case "join-room":
+							if len(commandParts) != 2 {
+								response = "Usage: join-room <name>\n"
+							} else {
+								response = controller.JoinChatRoom(loggedInUser, commandParts[1])
+							}
+						case "leave-room":
+							if len(commandParts) != 2 {
+								response = "Usage: leave-room <name>\n"
+							} else {
+								response = controller.LeaveChatRoom(loggedInUser, commandParts[1])
+							}
+						case "say":
+							if len(commandParts) < 3 {
+								response = "Usage: say <room> <message>\n"
+							} else {
+								response = controller.Say(loggedInUser, commandParts[1], argumentText(command, 2))
+							}
+
+						case "search":
+							// The query is the rest of the line; an optional trailing "page <n>" selects the page
+							query := strings.TrimSpace(strings.TrimPrefix(command, "search"))
+							page := 1
+							if n := len(commandParts); n >= 4 && commandParts[n-2] == "page" {
+								if p, err := strconv.Atoi(commandParts[n-1]); err == nil && p > 0 {
+									page = p
+									query = strings.TrimSpace(strings.Join(commandParts[1:n-2], " "))
+								}
+							}
+							response = controller.Search(loggedInUser, query, page)
+
+						// --- Reactions ---
+						case "like":
+							if len(commandParts) != 2 {
+								response = "Usage: like <blog-id>\n"
+							} else {
+								response = controller.React(loggedInUser, commandParts[1], "like")
+							}
+						case "react":
+							if len(commandParts) != 3 {
+								response = "Usage: react <blog-id> <" + strings.Join(models.ReactionKinds, "|") + ">\n"
+							} else {
+								response = controller.React(loggedInUser, commandParts[1], commandParts[2])
+							}
+						case "unreact":
+							if len(commandParts) != 2 {
+								response = "Usage: unreact <blog-id>\n"
+							} else {
+								response = controller.Unreact(loggedInUser, commandParts[1])
+							}
+						case "liked":
+							page, ok := parsePage(commandParts, 1)
+							if !ok {
+								response = "Usage: liked [page]\n"
+							} else {
+								response = controller.LikedPosts(loggedInUser, page)
+							}
+
+						// --- Comments ---
+						case "comments":
+							if len(commandParts) != 2 {
+								response = "Usage: comments <blog-id>\n"
+							} else {
+								response = controller.ViewComments(loggedInUser, commandParts[1])
+							}
+						case "comment":
+							if len(commandParts) != 2 {
+								response = "Usage: comment <blog-id>\n"
+							} else {
+								text := prompt(reader, writer, "Comment: ")
+								response = controller.AddComment(loggedInUser, commandParts[1], text)
+							}
+						case "reply":
+							if len(commandParts) != 2 {
+								response = "Usage: reply <comment-id>\n"
+							} else {
+								text := prompt(reader, writer, "Reply: ")
+								response = controller.ReplyToComment(loggedInUser, commandParts[1], text)
+							}
+						case "edit-comment":
+							if len(commandParts) != 2 {
+								response = "Usage: edit-comment <comment-id>\n"
+							} else {
+								text := prompt(reader, writer, "New Comment: ")
+								response = controller.EditComment(loggedInUser, commandParts[1], text)
+							}
+						case "delete-comment":
+							if len(commandParts) != 2 {
+								response = "Usage: delete-comment <comment-id>\n"
+							} else {
+								response = controller.DeleteComment(loggedInUser, commandParts[1])
+							}
+
 						// --- Admin Management ---
 						case "apply-admin":
-							response = controller.ApplyForAdmin(loggedInUser)
+							writer.WriteString("Why do you want to become an admin? ")
+							writer.Flush()
+							motivation, _ := reader.ReadString('\n')
+							motivation = strings.TrimSpace(motivation)
+							response = controller.ApplyForAdmin(loggedInUser, motivation)
+
+						case "my-applications":
+							response = controller.MyApplications(loggedInUser)
 
 						case "list-pending":
 							if !isAdmin {
@@ -285,13 +787,21 @@ func handleConnection(conn net.Conn, controller *controllers.UserController) {
 									writer.Flush()
 									username, _ := reader.ReadString('\n')
 									username = strings.TrimSpace(username)
-									response = controller.ApproveAdmin(username)
+									writer.WriteString("Reason (optional): ")
+									writer.Flush()
+									reason, _ := reader.ReadString('\n')
+									reason = strings.TrimSpace(reason)
+									response = controller.ApproveAdmin(username, loggedInUser, reason)
 								case "reject":
 									writer.WriteString("Username to reject: ")
 									writer.Flush()
 									username, _ := reader.ReadString('\n')
 									username = strings.TrimSpace(username)
-									response = controller.RejectAdmin(username)
+									writer.WriteString("Reason (optional): ")
+									writer.Flush()
+									reason, _ := reader.ReadString('\n')
+									reason = strings.TrimSpace(reason)
+									response = controller.RejectAdmin(username, loggedInUser, reason)
 								case "exit":
 									response = "Exiting pending approvals.\nReturning to main menu."
 									break
@@ -300,6 +810,78 @@ func handleConnection(conn net.Conn, controller *controllers.UserController) {
 
 								}
 							}
+						case "registrations":
+							if !isAdmin {
+								response = "You do not have permission to perform this action.\nReturning to main menu.\n"
+							} else {
+								response = controller.PendingRegistrations(loggedInUser)
+							}
+						case "approve-user":
+							if !isAdmin {
+								response = "You do not have permission to perform this action.\nReturning to main menu.\n"
+							} else if len(commandParts) != 2 {
+								response = "Usage: approve-user <username>\n"
+							} else {
+								response = controller.ApproveRegistration(loggedInUser, commandParts[1])
+							}
+						case "deny-user":
+							if !isAdmin {
+								response = "You do not have permission to perform this action.\nReturning to main menu.\n"
+							} else if len(commandParts) != 2 {
+								response = "Usage: deny-user <username>\n"
+							} else {
+								reason := prompt(reader, writer, "Reason (optional): ")
+								response = controller.DenyRegistration(loggedInUser, commandParts[1], reason)
+							}
+						case "demote":
+							if !isAdmin {
+								response = "You do not have permission to perform this action.\nReturning to main menu.\n"
+							} else if len(commandParts) != 2 {
+								response = "Usage: demote <username>\n"
+							} else {
+								writer.WriteString("Reason (optional): ")
+								writer.Flush()
+								reason, _ := reader.ReadString('\n')
+								reason = strings.TrimSpace(reason)
+								response = controller.DemoteAdmin(commandParts[1], loggedInUser, reason)
+							}
+						case "add-profile-field":
+							if !isAdmin {
+								response = "You do not have permission to perform this action.\nReturning to main menu.\n"
+							} else if field, ok := promptProfileField(reader, writer); !ok {
+								response = "Invalid number.\nReturning to main menu.\n"
+							} else {
+								response = controller.AddProfileField(loggedInUser, field)
+							}
+						case "remove-profile-field":
+							if !isAdmin {
+								response = "You do not have permission to perform this action.\nReturning to main menu.\n"
+							} else if len(commandParts) != 2 {
+								response = "Usage: remove-profile-field <key>\n"
+							} else {
+								response = controller.RemoveProfileField(loggedInUser, commandParts[1])
+							}
+						case "profile-stats":
+							if !isAdmin {
+								response = "You do not have permission to perform this action.\nReturning to main menu.\n"
+							} else {
+								response = controller.ProfileStats(loggedInUser)
+							}
+						case "username-report":
+							if !isAdmin {
+								response = "You do not have permission to perform this action.\nReturning to main menu.\n"
+							} else {
+								response = controller.UsernameReport(loggedInUser)
+							}
+						case "audit-log":
+							page, ok := parsePage(commandParts, 1)
+							if !isAdmin {
+								response = "You do not have permission to perform this action.\nReturning to main menu.\n"
+							} else if !ok {
+								response = "Usage: audit-log [page]\n"
+							} else {
+								response = controller.ViewAuditLog(loggedInUser, page)
+							}
 						case "list-users":
 							if !isAdmin {
 								response = "You do not have permission to perform this action.\nReturning to main menu.\n"
@@ -336,20 +918,295 @@ func handleConnection(conn net.Conn, controller *controllers.UserController) {
 		writer.Flush()
 	}
 }
+
+// profileFieldKeys lists the keys accepted by "set-profile"
+func profileFieldKeys(controller *controllers.UserController) string {
+	keys := []string{}
+	for _, field := range controller.GetProfileSchema() {
+		keys = append(keys, field.Key)
+	}
+	return strings.Join(keys, ", ")
+}
+
+// argumentText returns what follows the first n words of a command line, keeping the spacing within it
+func argumentText(command string, n int) string {
+	rest := strings.TrimSpace(command)
+	for i := 0; i < n; i++ {
+		rest = strings.TrimLeftFunc(rest, func(r rune) bool { return !unicode.IsSpace(r) })
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+	}
+	return strings.TrimRightFunc(rest, unicode.IsSpace)
+}
+
+// prompt writes a label to the client and returns the next line it sends, without surrounding whitespace
+func prompt(reader *bufio.Reader, writer *sessionWriter, label string) string {
+	writer.WriteString(label)
+	writer.Flush()
+	input, _ := reader.ReadString('\n')
+	return strings.TrimSpace(input)
+}
+
+// promptMultiline reads a multi-line body from the client until a line containing only ".".
+// A line that should start with "." is sent with an extra leading "." which is removed, as in SMTP.
+// Input beyond maxBytes is read and discarded up to the terminator so the connection stays in sync.
+func promptMultiline(reader *bufio.Reader, writer *sessionWriter, label string, maxBytes int) (string, error) {
+	writer.WriteString(label + " (finish with a line containing only \".\"):\n")
+	writer.Flush()
+
+	var body strings.Builder
+	tooLong := false
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "." {
+			break
+		}
+		line = strings.TrimPrefix(line, ".")
+		if tooLong {
+			continue
+		}
+		if body.Len() > 0 {
+			body.WriteString("\n")
+		}
+		body.WriteString(line)
+		if body.Len() > maxBytes {
+			tooLong = true
+		}
+	}
+	if tooLong {
+		return "", fmt.Errorf("blog text is too long (maximum is %d bytes)", maxBytes)
+	}
+	return strings.Trim(body.String(), "\n"), nil
+}
+
+// promptProfileField asks an admin for the definition of a new custom profile field.
+// It reports false if a number was expected but something else was entered.
+func promptProfileField(reader *bufio.Reader, writer *sessionWriter) (models.ProfileField, bool) {
+	field := models.ProfileField{
+		Key:      prompt(reader, writer, "Field key (lowercase letters, digits and \"-\"): "),
+		Label:    prompt(reader, writer, "Label shown to users: "),
+		Type:     prompt(reader, writer, "Type (text/number/year): "),
+		Required: prompt(reader, writer, "Required? (yes/no): ") == "yes",
+	}
+	switch field.Type {
+	case "text":
+		if maxLength := prompt(reader, writer, "Maximum length (empty for the default): "); maxLength != "" {
+			number, err := strconv.Atoi(maxLength)
+			if err != nil {
+				return field, false
+			}
+			field.MaxLength = number
+		}
+		field.Pattern = prompt(reader, writer, "Pattern the value must match (regular expression, empty for any): ")
+	case "number":
+		min, err := strconv.Atoi(prompt(reader, writer, "Minimum: "))
+		if err != nil {
+			return field, false
+		}
+		max, err := strconv.Atoi(prompt(reader, writer, "Maximum: "))
+		if err != nil {
+			return field, false
+		}
+		field.Min, field.Max = min, max
+	case "year":
+		if min := prompt(reader, writer, "Earliest year (empty for 1900): "); min != "" {
+			number, err := strconv.Atoi(min)
+			if err != nil {
+				return field, false
+			}
+			field.Min = number
+		}
+		if max := prompt(reader, writer, "Latest year (empty for the current year): "); max != "" {
+			number, err := strconv.Atoi(max)
+			if err != nil {
+				return field, false
+			}
+			field.Max = number
+		}
+	}
+	field.Visibility = prompt(reader, writer, "Default visibility (public/followers/private) [public]: ")
+	return field, true
+}
+
+// maxRequiredPrompts is how many more times the profile wizard asks for a required field left empty
+const maxRequiredPrompts = 2
+
+// publishTimeLayout is the format clients use to enter publish times, in the server's local time zone
+const publishTimeLayout = "2006-01-02 15:04"
+
+// promptPublishing asks whether a new blog should be published now, kept as a draft or scheduled
+func promptPublishing(reader *bufio.Reader, writer *sessionWriter) (string, time.Time, bool) {
+	switch prompt(reader, writer, "Publish now, save as draft or schedule? (publish/draft/schedule): ") {
+	case "", "publish":
+		return "published", time.Time{}, true
+	case "draft":
+		return "draft", time.Time{}, true
+	case "schedule":
+		publishAt, err := time.ParseInLocation(publishTimeLayout, prompt(reader, writer, "Publish at (YYYY-MM-DD HH:MM): "), time.Local)
+		if err != nil {
+			return "", time.Time{}, false
+		}
+		return "scheduled", publishAt, true
+	}
+	return "", time.Time{}, false
+}
+
+// promptBlog asks the client to pick one of the listed blogs by its number
+func promptBlog(reader *bufio.Reader, writer *sessionWriter, blogs []models.Blog, action string) (models.Blog, bool) {
+	if len(blogs) == 0 {
+		return models.Blog{}, false
+	}
+	index, err := strconv.Atoi(prompt(reader, writer, "Enter the blog number to "+action+": "))
+	if err != nil || index < 1 || index > len(blogs) {
+		return models.Blog{}, false
+	}
+	return blogs[index-1], true
+}
+
+// parseTags splits a line of tags separated by spaces or commas
+func parseTags(input string) []string {
+	return strings.FieldsFunc(input, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
+
+// parsePage reads an optional page number at the given position of a command, defaulting to the first page
+func parsePage(commandParts []string, position int) (int, bool) {
+	if len(commandParts) <= position {
+		return 1, true
+	}
+	if len(commandParts) > position+1 {
+		return 0, false
+	}
+	page, err := strconv.Atoi(commandParts[position])
+	if err != nil || page < 1 {
+		return 0, false
+	}
+	return page, true
+}
+
 func displayMenu(isAdmin bool) string {
 	if isAdmin {
 		return "Available commands:\n" +
 			"- list-pending\n" +
 			"- list-users\n" +
+			"- demote <username>\n" +
+			"- audit-log [page]\n" +
+			"- registrations\n" +
+			"- approve-user <username>\n" +
+			"- deny-user <username>\n" +
+			"- invites [page]\n" +
+			"- invite-tree [username]\n" +
+			"- profile-stats\n" +
+			"- username-report\n" +
+			"- add-profile-field\n" +
+			"- remove-profile-field <key>\n" +
 			"- view-profile\n" +
+			"- set-profile <field> [value]\n" +
+			"- profile-visibility <field> <public|followers|private>\n" +
+			"- profile <username>\n" +
+			"- profile-fields\n" +
+			"- discover [page]\n" +
 			"- my-blogs\n" +
+			"- feed [page]\n" +
+			"- timeline [page]\n" +
+			"- follow <username>\n" +
+			"- unfollow <username>\n" +
+			"- followers [username]\n" +
+			"- following [username]\n" +
+			"- blogs <username> [page]\n" +
+			"- read <blog-id>\n" +
+			"- send <username> <message>\n" +
+			"- inbox\n" +
+			"- conversation <username> [page]\n" +
+			"- block <username>\n" +
+			"- unblock <username>\n" +
+			"- blocked\n" +
+			"- who\n" +
+			"- presence <username>\n" +
+			"- hide-presence\n" +
+			"- show-presence\n" +
+			"- rooms\n" +
+			"- create-room <name>\n" +
+			"- join-room <name>\n" +
+			"- leave-room <name>\n" +
+			"- say <room> <message>\n" +
+			"- search <query> [page <n>]\n" +
+			"- tags\n" +
+			"- tagged <tag> [page]\n" +
+			"- like <blog-id>\n" +
+			"- react <blog-id> <reaction>\n" +
+			"- unreact <blog-id>\n" +
+			"- liked [page]\n" +
+			"- comments <blog-id>\n" +
+			"- comment <blog-id>\n" +
+			"- reply <comment-id>\n" +
+			"- edit-comment <comment-id>\n" +
+			"- delete-comment <comment-id>\n" +
 			"- apply-admin\n" +
+			"- my-applications\n" +
+			"- create-invite [uses] [days]\n" +
+			"- my-invites\n" +
+			"- revoke-invite <code>\n" +
+			"- export-my-data\n" +
+			"- delete-account\n" +
+			"- cancel-deletion\n" +
 			"- exit\n"
 	} else {
 		return "Available commands:\n" +
 			"- view-profile\n" +
+			"- set-profile <field> [value]\n" +
+			"- profile-visibility <field> <public|followers|private>\n" +
+			"- profile <username>\n" +
+			"- profile-fields\n" +
+			"- discover [page]\n" +
 			"- my-blogs\n" +
+			"- feed [page]\n" +
+			"- timeline [page]\n" +
+			"- follow <username>\n" +
+			"- unfollow <username>\n" +
+			"- followers [username]\n" +
+			"- following [username]\n" +
+			"- blogs <username> [page]\n" +
+			"- read <blog-id>\n" +
+			"- send <username> <message>\n" +
+			"- inbox\n" +
+			"- conversation <username> [page]\n" +
+			"- block <username>\n" +
+			"- unblock <username>\n" +
+			"- blocked\n" +
+			"- who\n" +
+			"- presence <username>\n" +
+			"- hide-presence\n" +
+			"- show-presence\n" +
+			"- rooms\n" +
+			"- create-room <name>\n" +
+			"- join-room <name>\n" +
+			"- leave-room <name>\n" +
+			"- say <room> <message>\n" +
+			"- search <query> [page <n>]\n" +
+			"- tags\n" +
+			"- tagged <tag> [page]\n" +
+			"- like <blog-id>\n" +
+			"- react <blog-id> <reaction>\n" +
+			"- unreact <blog-id>\n" +
+			"- liked [page]\n" +
+			"- comments <blog-id>\n" +
+			"- comment <blog-id>\n" +
+			"- reply <comment-id>\n" +
+			"- edit-comment <comment-id>\n" +
+			"- delete-comment <comment-id>\n" +
 			"- apply-admin\n" +
+			"- my-applications\n" +
+			"- create-invite [uses] [days]\n" +
+			"- my-invites\n" +
+			"- revoke-invite <code>\n" +
+			"- export-my-data\n" +
+			"- delete-account\n" +
+			"- cancel-deletion\n" +
 			"- exit\n"
 	}
 }
@@ -0,0 +1,280 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DeletedUserPlaceholder replaces the author of content kept after its author's account was deleted.
+// It can never be registered because it breaks every username rule.
+const DeletedUserPlaceholder = "[deleted]"
+
+// UserData is everything stored about a user, as returned by ExportUserData. The password hash is left out.
+type UserData struct {
+	Username          string
+	Role              string
+	Status            string
+	RegisteredAt      time.Time
+	InvitedWith       string
+	LastSeen          time.Time
+	DeleteAfter       time.Time
+	Profile           map[string]string // Profile field key -> value, including custom fields
+	ProfileVisibility map[string]string
+	HidePresence      bool
+	Blogs             []Blog
+	Comments          []Comment
+	Reactions         []Reaction
+	Messages          []Message
+	Following         []string
+	Blocked           []string
+	ChatRooms         []string
+	AdminApplications []AdminApplication
+	Invites           []InviteCode // Invite codes the user created
+}
+
+// --- Account Methods ---
+
+// ScheduleAccountDeletion records when a user's account is to be deleted and saves the changes to the file.
+// An admin's account is only scheduled if another admin will remain.
+func (repo *InMemoryUserRepository) ScheduleAccountDeletion(username string, deleteAfter time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	user, exists := repo.Users[username]
+	if !exists {
+		return fmt.Errorf("User not found")
+	}
+	if !user.DeleteAfter.IsZero() {
+		return fmt.Errorf("Account deletion is already scheduled")
+	}
+	if user.Role == "admin" && repo.countRemainingAdmins(username) == 0 {
+		return fmt.Errorf("The last admin cannot delete their account")
+	}
+	user.DeleteAfter = deleteAfter
+	repo.Users[username] = user
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// CancelAccountDeletion keeps an account that was scheduled for deletion and saves the changes to the file
+func (repo *InMemoryUserRepository) CancelAccountDeletion(username string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	user, exists := repo.Users[username]
+	if !exists {
+		return fmt.Errorf("User not found")
+	}
+	if user.DeleteAfter.IsZero() {
+		return fmt.Errorf("Account deletion is not scheduled")
+	}
+	user.DeleteAfter = time.Time{}
+	repo.Users[username] = user
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// GetAccountsDueForDeletion returns the usernames whose grace period has ended by now, sorted
+func (repo *InMemoryUserRepository) GetAccountsDueForDeletion(now time.Time) []string {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	due := []string{}
+	for username, user := range repo.Users {
+		if !user.DeleteAfter.IsZero() && !user.DeleteAfter.After(now) {
+			due = append(due, username)
+		}
+	}
+	sort.Strings(due)
+	return due
+}
+
+// PurgeUser deletes a user for good and saves the changes to the file. Their blogs, comments, messages and
+// chat messages are either removed or, with anonymize set, kept under DeletedUserPlaceholder.
+// The account must be due for deletion at now, so a deletion cancelled since it was found due is not carried out.
+// An admin is only deleted while another admin remains.
+func (repo *InMemoryUserRepository) PurgeUser(username string, anonymize bool, now time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	user, exists := repo.Users[username]
+	if !exists {
+		return fmt.Errorf("User not found")
+	}
+	if user.DeleteAfter.IsZero() || user.DeleteAfter.After(now) {
+		return fmt.Errorf("Account is not due for deletion")
+	}
+	// Checked again here in case other admins were scheduled for deletion in the meantime
+	if user.Role == "admin" && repo.countRemainingAdmins(username) == 0 {
+		return fmt.Errorf("The last admin cannot be deleted")
+	}
+	repo.deleteUserContent(username, anonymize)
+	repo.deleteUser(username)
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// deleteUserContent removes a user's blogs, comments, messages and chat messages or, with anonymize set,
+// hands them to DeletedUserPlaceholder; the caller must hold the lock and is responsible for saving
+func (repo *InMemoryUserRepository) deleteUserContent(username string, anonymize bool) {
+	for _, blog := range repo.getBlogsByAuthor(username) {
+		if !anonymize {
+			repo.deleteBlog(blog)
+			continue
+		}
+		delete(repo.authorIndex[username], blog.ID)
+		blog.Author = DeletedUserPlaceholder
+		repo.Blogs[blog.ID] = blog
+		repo.indexAuthor(blog)
+	}
+	delete(repo.authorIndex, username)
+
+	commentIDs := []string{}
+	for commentID, comment := range repo.Comments {
+		if comment.Author == username {
+			commentIDs = append(commentIDs, commentID)
+		}
+	}
+	for _, commentID := range commentIDs {
+		// Removing a comment may prune parents, so look each one up again
+		comment, exists := repo.Comments[commentID]
+		if !exists {
+			continue
+		}
+		if anonymize {
+			comment.Author = DeletedUserPlaceholder
+			repo.Comments[commentID] = comment
+		} else {
+			repo.deleteComment(comment)
+		}
+	}
+
+	for messageID, message := range repo.Messages {
+		if message.From != username && message.To != username {
+			continue
+		}
+		if !anonymize {
+			delete(repo.Messages, messageID)
+			continue
+		}
+		if message.From == username {
+			message.From = DeletedUserPlaceholder
+		}
+		if message.To == username {
+			message.To = DeletedUserPlaceholder
+		}
+		repo.Messages[messageID] = message
+	}
+
+	for name, room := range repo.ChatRooms {
+		history := []ChatMessage{}
+		for _, message := range room.History {
+			if message.From == username {
+				if !anonymize {
+					continue
+				}
+				message.From = DeletedUserPlaceholder
+			}
+			history = append(history, message)
+		}
+		room.History = history
+		repo.ChatRooms[name] = room
+	}
+}
+
+// countRemainingAdmins returns the number of admins other than the given user who are not scheduled for deletion;
+// the caller must hold the lock
+func (repo *InMemoryUserRepository) countRemainingAdmins(username string) int {
+	count := 0
+	for _, user := range repo.Users {
+		if user.Username != username && user.Role == "admin" && user.DeleteAfter.IsZero() {
+			count++
+		}
+	}
+	return count
+}
+
+// ExportUserData gathers everything stored about a user
+func (repo *InMemoryUserRepository) ExportUserData(username string) (UserData, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	user, exists := repo.Users[username]
+	if !exists {
+		return UserData{}, fmt.Errorf("User not found")
+	}
+	data := UserData{
+		Username:          user.Username,
+		Role:              user.Role,
+		Status:            user.Status,
+		RegisteredAt:      user.RegisteredAt,
+		InvitedWith:       user.InvitedWith,
+		LastSeen:          user.LastSeen,
+		DeleteAfter:       user.DeleteAfter,
+		Profile:           make(map[string]string),
+		ProfileVisibility: user.ProfileVisibility,
+		HidePresence:      user.HidePresence,
+		Blogs:             repo.getBlogsByAuthor(username),
+		Comments:          []Comment{},
+		Reactions:         []Reaction{},
+		Messages:          []Message{},
+		Following:         repo.getFollowing(username),
+		Blocked:           []string{},
+		ChatRooms:         []string{},
+		AdminApplications: []AdminApplication{},
+		Invites:           []InviteCode{},
+	}
+	for _, field := range append(append([]ProfileField{}, builtInProfileFields...), repo.ProfileSchema...) {
+		data.Profile[field.Key] = user.ProfileField(field.Key)
+	}
+	for _, comment := range repo.Comments {
+		if comment.Author == username {
+			data.Comments = append(data.Comments, comment)
+		}
+	}
+	sort.Slice(data.Comments, func(i, j int) bool {
+		return data.Comments[i].CreatedAt.Before(data.Comments[j].CreatedAt)
+	})
+	for _, reaction := range repo.Reactions {
+		if reaction.Username == username {
+			data.Reactions = append(data.Reactions, reaction)
+		}
+	}
+	sort.Slice(data.Reactions, func(i, j int) bool {
+		return data.Reactions[i].CreatedAt.Before(data.Reactions[j].CreatedAt)
+	})
+	for _, message := range repo.Messages {
+		if message.From == username || message.To == username {
+			data.Messages = append(data.Messages, message)
+		}
+	}
+	sortMessages(data.Messages)
+	for _, block := range repo.Blocks {
+		if block.Blocker == username {
+			data.Blocked = append(data.Blocked, block.Blocked)
+		}
+	}
+	sort.Strings(data.Blocked)
+	for name, room := range repo.ChatRooms {
+		if room.HasMember(username) {
+			data.ChatRooms = append(data.ChatRooms, name)
+		}
+	}
+	sort.Strings(data.ChatRooms)
+	for _, application := range repo.Applications {
+		if application.Applicant == username {
+			data.AdminApplications = append(data.AdminApplications, application)
+		}
+	}
+	sortApplications(data.AdminApplications)
+	for _, invite := range repo.Invites {
+		if invite.CreatedBy == username {
+			data.Invites = append(data.Invites, invite)
+		}
+	}
+	sort.Slice(data.Invites, func(i, j int) bool {
+		return data.Invites[i].CreatedAt.Before(data.Invites[j].CreatedAt)
+	})
+	return data, nil
+}
@@ -0,0 +1,204 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// AdminApplication represents a user's request to be granted the admin role
+type AdminApplication struct {
+	ID         string // Unique ID for the application
+	Applicant  string // Username of the user applying
+	Motivation string // Free-form text explaining why the user wants admin rights
+	CreatedAt  time.Time
+	Approvals  []string // Usernames of the admins who voted to approve
+	DecidedBy  string   // Username of the admin who decided the application
+	Decision   string   // "pending", "approved" or "rejected"
+	Reason     string   // Optional reason given with the decision
+	DecidedAt  time.Time
+}
+
+// --- Admin Application Methods ---
+
+// CreateAdminApplication records a new pending admin application for the user and saves the changes to the file
+func (repo *InMemoryUserRepository) CreateAdminApplication(username, motivation string) (AdminApplication, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	user, exists := repo.Users[username]
+	if !exists {
+		return AdminApplication{}, fmt.Errorf("User not found")
+	}
+	if user.Role == "admin" {
+		return AdminApplication{}, fmt.Errorf("User is already an admin")
+	}
+	if _, err := repo.findPendingApplication(username); err == nil {
+		return AdminApplication{}, fmt.Errorf("Admin application already pending")
+	}
+
+	application := AdminApplication{
+		ID:         generateID(),
+		Applicant:  username,
+		Motivation: motivation,
+		CreatedAt:  time.Now(),
+		Decision:   "pending",
+	}
+	repo.Applications[application.ID] = application
+	repo.saveToFile() // Persist changes to the file
+	return application, nil
+}
+
+// FindPendingApplication retrieves the pending admin application of a user, if any
+func (repo *InMemoryUserRepository) FindPendingApplication(username string) (AdminApplication, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return repo.findPendingApplication(username)
+}
+
+// findPendingApplication retrieves the pending admin application of a user, if any
+func (repo *InMemoryUserRepository) findPendingApplication(username string) (AdminApplication, error) {
+	for _, application := range repo.Applications {
+		if application.Applicant == username && application.Decision == "pending" {
+			return application, nil
+		}
+	}
+	return AdminApplication{}, fmt.Errorf("No pending application for user")
+}
+
+// GetPendingApplications returns all admin applications awaiting a decision, oldest first
+func (repo *InMemoryUserRepository) GetPendingApplications() []AdminApplication {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	pending := []AdminApplication{}
+	for _, application := range repo.Applications {
+		if application.Decision == "pending" {
+			pending = append(pending, application)
+		}
+	}
+	sortApplications(pending)
+	return pending
+}
+
+// GetApplicationsByUser returns the full application history of a user, oldest first
+func (repo *InMemoryUserRepository) GetApplicationsByUser(username string) []AdminApplication {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	applications := []AdminApplication{}
+	for _, application := range repo.Applications {
+		if application.Applicant == username {
+			applications = append(applications, application)
+		}
+	}
+	sortApplications(applications)
+	return applications
+}
+
+// ApproveAdminApplication adds an admin's approval vote to the pending application of a user and saves the changes to the file.
+// The application is approved once it has the votes required by the quorum, counting only admins not scheduled for deletion.
+// It returns the number of approvals collected so far and the number required.
+func (repo *InMemoryUserRepository) ApproveAdminApplication(username, admin, reason string, quorum int) (int, int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	application, err := repo.findPendingApplication(username)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, voter := range application.Approvals {
+		if voter == admin {
+			return 0, 0, fmt.Errorf("You have already approved this application")
+		}
+	}
+	application.Approvals = append(application.Approvals, admin)
+
+	// Passing no username counts every admin not scheduled for deletion
+	required := requiredApprovals(quorum, repo.countRemainingAdmins(""))
+	if len(application.Approvals) < required {
+		repo.Applications[application.ID] = application
+	} else if err := repo.decideAdminApplication(application, admin, "approved", reason); err != nil {
+		return 0, 0, err
+	}
+	repo.saveToFile() // Persist changes to the file
+	return len(application.Approvals), required, nil
+}
+
+// requiredApprovals returns the number of votes needed for a role change given the number of eligible admins.
+// The configured quorum is capped so that a small admin team is never locked out.
+func requiredApprovals(quorum, eligible int) int {
+	if eligible < 1 {
+		eligible = 1
+	}
+	if quorum > eligible {
+		return eligible
+	}
+	return quorum
+}
+
+// deleteApplicationsByUser removes all admin applications of a user, with their motivations, and withdraws
+// the user's approvals from pending applications; the caller must hold the lock and is responsible for saving
+func (repo *InMemoryUserRepository) deleteApplicationsByUser(username string) {
+	for id, application := range repo.Applications {
+		if application.Applicant == username {
+			delete(repo.Applications, id)
+			continue
+		}
+		if application.Decision == "pending" {
+			application.Approvals = withoutVoter(application.Approvals, username)
+			repo.Applications[id] = application
+		}
+	}
+}
+
+// withoutVoter returns the votes cast by anyone but the given user
+func withoutVoter(votes []string, username string) []string {
+	remaining := []string{}
+	for _, voter := range votes {
+		if voter != username {
+			remaining = append(remaining, voter)
+		}
+	}
+	return remaining
+}
+
+// DecideAdminApplication approves or rejects the pending application of a user and saves the changes to the file.
+// The applicant's role is only changed when the application is approved.
+func (repo *InMemoryUserRepository) DecideAdminApplication(username, decidedBy, decision, reason string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if decision != "approved" && decision != "rejected" {
+		return fmt.Errorf("Invalid decision: %s", decision)
+	}
+	application, err := repo.findPendingApplication(username)
+	if err != nil {
+		return err
+	}
+	if err := repo.decideAdminApplication(application, decidedBy, decision, reason); err != nil {
+		return err
+	}
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// decideAdminApplication records the decision on a pending application and grants the admin role when it is approved;
+// the caller must hold the lock and is responsible for saving
+func (repo *InMemoryUserRepository) decideAdminApplication(application AdminApplication, decidedBy, decision, reason string) error {
+	user, exists := repo.Users[application.Applicant]
+	if !exists {
+		return fmt.Errorf("User not found")
+	}
+
+	application.Decision = decision
+	application.DecidedBy = decidedBy
+	application.Reason = reason
+	application.DecidedAt = time.Now()
+	repo.Applications[application.ID] = application
+
+	if decision == "approved" {
+		user.Role = "admin"
+		repo.Users[application.Applicant] = user
+	}
+	return nil
+}
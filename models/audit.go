@@ -0,0 +1,50 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// AuditEntry records a privileged action, such as an admin reading content they could not otherwise see
+type AuditEntry struct {
+	ID     string
+	Time   time.Time
+	Actor  string // Username of the user who performed the action
+	Action string // Short machine-friendly name, e.g. "read-blog-override"
+	Target string // ID or username the action was performed on
+	Detail string
+}
+
+// --- Audit Methods ---
+
+// AddAuditEntry appends an entry to the audit log and saves the changes to the file
+func (repo *InMemoryUserRepository) AddAuditEntry(actor, action, target, detail string) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	entry := AuditEntry{
+		ID:     generateID(),
+		Time:   time.Now(),
+		Actor:  actor,
+		Action: action,
+		Target: target,
+		Detail: detail,
+	}
+	repo.AuditLog[entry.ID] = entry
+	repo.saveToFile() // Persist changes to the file
+}
+
+// GetAuditLog returns every audit entry, newest first
+func (repo *InMemoryUserRepository) GetAuditLog() []AuditEntry {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	entries := []AuditEntry{}
+	for _, entry := range repo.AuditLog {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Time.After(entries[j].Time)
+	})
+	return entries
+}
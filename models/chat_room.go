@@ -0,0 +1,163 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ChatRoom represents a named room whose messages are fanned out to every member
+type ChatRoom struct {
+	Name      string
+	Owner     string // Username of the user who created the room
+	Members   []string
+	CreatedAt time.Time
+	History   []ChatMessage // Most recent messages, oldest first, kept up to the scrollback limit
+}
+
+// ChatMessage represents a single message posted to a chat room
+type ChatMessage struct {
+	Room   string
+	From   string
+	Text   string
+	SentAt time.Time
+}
+
+// HasMember reports whether the user is a member of the room
+func (room ChatRoom) HasMember(username string) bool {
+	for _, member := range room.Members {
+		if member == username {
+			return true
+		}
+	}
+	return false
+}
+
+// --- Chat Room Methods ---
+
+// CreateChatRoom creates a room with its owner as the first member and saves the changes to the file
+func (repo *InMemoryUserRepository) CreateChatRoom(name, owner string) (ChatRoom, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.ChatRooms[name]; exists {
+		return ChatRoom{}, fmt.Errorf("Chat room already exists")
+	}
+	room := ChatRoom{
+		Name:      name,
+		Owner:     owner,
+		Members:   []string{owner},
+		CreatedAt: time.Now(),
+	}
+	repo.ChatRooms[name] = room
+	repo.saveToFile() // Persist changes to the file
+	return room, nil
+}
+
+// FindChatRoom retrieves a chat room by name
+func (repo *InMemoryUserRepository) FindChatRoom(name string) (ChatRoom, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	room, exists := repo.ChatRooms[name]
+	if !exists {
+		return ChatRoom{}, fmt.Errorf("Chat room not found")
+	}
+	return room, nil
+}
+
+// JoinChatRoom adds the user to the members of a room and saves the changes to the file.
+// Joining a room the user is already in is not an error; the room is returned either way.
+func (repo *InMemoryUserRepository) JoinChatRoom(name, username string) (ChatRoom, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	room, exists := repo.ChatRooms[name]
+	if !exists {
+		return ChatRoom{}, fmt.Errorf("Chat room not found")
+	}
+	if room.HasMember(username) {
+		return room, nil
+	}
+	room.Members = append(room.Members, username)
+	repo.ChatRooms[name] = room
+	repo.saveToFile() // Persist changes to the file
+	return room, nil
+}
+
+// LeaveChatRoom removes the user from the members of a room and saves the changes to the file
+func (repo *InMemoryUserRepository) LeaveChatRoom(name, username string) (ChatRoom, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	room, exists := repo.ChatRooms[name]
+	if !exists {
+		return ChatRoom{}, fmt.Errorf("Chat room not found")
+	}
+	if !room.HasMember(username) {
+		return ChatRoom{}, fmt.Errorf("You are not a member of this chat room")
+	}
+	room.Members = removeMember(room.Members, username)
+	repo.ChatRooms[name] = room
+	repo.saveToFile() // Persist changes to the file
+	return room, nil
+}
+
+// AddChatMessage appends a message from a member to the history of a room, keeping at most scrollback messages,
+// and saves the changes to the file. The room is returned so the caller can fan the message out to its members.
+func (repo *InMemoryUserRepository) AddChatMessage(name, from, text string, scrollback int) (ChatRoom, ChatMessage, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	room, exists := repo.ChatRooms[name]
+	if !exists {
+		return ChatRoom{}, ChatMessage{}, fmt.Errorf("Chat room not found")
+	}
+	if !room.HasMember(from) {
+		return ChatRoom{}, ChatMessage{}, fmt.Errorf("You are not a member of this chat room")
+	}
+	message := ChatMessage{Room: name, From: from, Text: text, SentAt: time.Now()}
+	room.History = append(room.History, message)
+	if len(room.History) > scrollback {
+		room.History = append([]ChatMessage{}, room.History[len(room.History)-scrollback:]...)
+	}
+	repo.ChatRooms[name] = room
+	repo.saveToFile() // Persist changes to the file
+	return room, message, nil
+}
+
+// GetChatRooms returns every chat room sorted by name
+func (repo *InMemoryUserRepository) GetChatRooms() []ChatRoom {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	rooms := []ChatRoom{}
+	for _, room := range repo.ChatRooms {
+		rooms = append(rooms, room)
+	}
+	sort.Slice(rooms, func(i, j int) bool {
+		return rooms[i].Name < rooms[j].Name
+	})
+	return rooms
+}
+
+// deleteChatMembershipsByUser removes a user from every chat room; the caller is responsible for saving
+func (repo *InMemoryUserRepository) deleteChatMembershipsByUser(username string) {
+	for name, room := range repo.ChatRooms {
+		if room.HasMember(username) {
+			room.Members = removeMember(room.Members, username)
+			repo.ChatRooms[name] = room
+		}
+	}
+}
+
+// removeMember returns the members without the given user
+func removeMember(members []string, username string) []string {
+	remaining := []string{}
+	for _, member := range members {
+		if member != username {
+			remaining = append(remaining, member)
+		}
+	}
+	return remaining
+}
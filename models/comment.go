@@ -0,0 +1,179 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Comment represents a response to a blog post or, when ParentID is set, a reply to another comment
+type Comment struct {
+	ID        string // Unique ID for the comment
+	BlogID    string // ID of the blog the comment belongs to
+	ParentID  string // ID of the comment being replied to, empty for top-level comments
+	Author    string // Username of the comment's author
+	Text      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Deleted   bool // Deleted comments with replies are kept so the thread stays intact
+}
+
+// --- Comment Methods ---
+
+// CreateComment adds a comment to a blog, optionally as a reply to another comment, and saves the changes to the file
+func (repo *InMemoryUserRepository) CreateComment(blogID, parentID, author, text string) (Comment, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.Blogs[blogID]; !exists {
+		return Comment{}, fmt.Errorf("Blog not found")
+	}
+	if parentID != "" {
+		parent, exists := repo.Comments[parentID]
+		if !exists || parent.BlogID != blogID {
+			return Comment{}, fmt.Errorf("Comment not found")
+		}
+		if parent.Deleted {
+			return Comment{}, fmt.Errorf("Cannot reply to a deleted comment")
+		}
+	}
+	now := time.Now()
+	comment := Comment{
+		ID:        generateID(),
+		BlogID:    blogID,
+		ParentID:  parentID,
+		Author:    author,
+		Text:      text,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	repo.Comments[comment.ID] = comment
+	repo.saveToFile() // Persist changes to the file
+	return comment, nil
+}
+
+// FindCommentByID retrieves a comment by its ID
+func (repo *InMemoryUserRepository) FindCommentByID(commentID string) (Comment, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return repo.findCommentByID(commentID)
+}
+
+// findCommentByID retrieves a comment by its ID
+func (repo *InMemoryUserRepository) findCommentByID(commentID string) (Comment, error) {
+	comment, exists := repo.Comments[commentID]
+	if !exists || comment.Deleted {
+		return Comment{}, fmt.Errorf("Comment not found")
+	}
+	return comment, nil
+}
+
+// UpdateComment allows a user to edit their own comment and saves the changes to the file
+func (repo *InMemoryUserRepository) UpdateComment(username, commentID, text string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	comment, err := repo.findCommentByID(commentID)
+	if err != nil {
+		return err
+	}
+	if comment.Author != username {
+		return fmt.Errorf("You are not the author of this comment")
+	}
+	comment.Text = text
+	comment.UpdatedAt = time.Now()
+	repo.Comments[commentID] = comment
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// DeleteComment removes a comment and saves the changes to the file.
+// A comment that has replies is blanked out instead so the replies keep their place in the thread.
+func (repo *InMemoryUserRepository) DeleteComment(commentID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	comment, err := repo.findCommentByID(commentID)
+	if err != nil {
+		return err
+	}
+	repo.deleteComment(comment)
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// deleteComment removes a comment, or blanks it if it has replies so the thread stays intact;
+// the caller must hold the lock and is responsible for saving
+func (repo *InMemoryUserRepository) deleteComment(comment Comment) {
+	if repo.hasReplies(comment.ID) {
+		comment.Deleted = true
+		comment.Text = ""
+		comment.UpdatedAt = time.Now()
+		repo.Comments[comment.ID] = comment
+	} else {
+		delete(repo.Comments, comment.ID)
+		repo.pruneDeletedParents(comment.ParentID)
+	}
+}
+
+// GetCommentsByBlog returns all comments on a blog, including deleted placeholders, oldest first
+func (repo *InMemoryUserRepository) GetCommentsByBlog(blogID string) []Comment {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	comments := []Comment{}
+	for _, comment := range repo.Comments {
+		if comment.BlogID == blogID {
+			comments = append(comments, comment)
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+	})
+	return comments
+}
+
+// CountComments returns the number of visible comments on a blog
+func (repo *InMemoryUserRepository) CountComments(blogID string) int {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	count := 0
+	for _, comment := range repo.Comments {
+		if comment.BlogID == blogID && !comment.Deleted {
+			count++
+		}
+	}
+	return count
+}
+
+// deleteCommentsByBlog removes every comment on a blog; the caller is responsible for saving
+func (repo *InMemoryUserRepository) deleteCommentsByBlog(blogID string) {
+	for commentID, comment := range repo.Comments {
+		if comment.BlogID == blogID {
+			delete(repo.Comments, commentID)
+		}
+	}
+}
+
+// hasReplies reports whether any comment replies to the given comment
+func (repo *InMemoryUserRepository) hasReplies(commentID string) bool {
+	for _, comment := range repo.Comments {
+		if comment.ParentID == commentID {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneDeletedParents removes deleted placeholders that no longer have any replies, walking up the thread
+func (repo *InMemoryUserRepository) pruneDeletedParents(commentID string) {
+	for commentID != "" {
+		comment, exists := repo.Comments[commentID]
+		if !exists || !comment.Deleted || repo.hasReplies(commentID) {
+			return
+		}
+		delete(repo.Comments, commentID)
+		commentID = comment.ParentID
+	}
+}
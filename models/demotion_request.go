@@ -0,0 +1,120 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// DemotionRequest represents a proposal by admins to remove the admin role from another admin
+type DemotionRequest struct {
+	ID          string // Unique ID for the request
+	Target      string // Username of the admin to demote
+	RequestedBy string // Username of the admin who opened the request
+	Reason      string
+	CreatedAt   time.Time
+	Approvals   []string // Usernames of the admins who voted for the demotion
+	Status      string   // "pending" or "completed"
+	CompletedAt time.Time
+}
+
+// --- Demotion Methods ---
+
+// FindPendingDemotion retrieves the pending demotion request for an admin, if any
+func (repo *InMemoryUserRepository) FindPendingDemotion(target string) (DemotionRequest, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return repo.findPendingDemotion(target)
+}
+
+// findPendingDemotion retrieves the pending demotion request for an admin, if any
+func (repo *InMemoryUserRepository) findPendingDemotion(target string) (DemotionRequest, error) {
+	for _, request := range repo.Demotions {
+		if request.Target == target && request.Status == "pending" {
+			return request, nil
+		}
+	}
+	return DemotionRequest{}, fmt.Errorf("No pending demotion for user")
+}
+
+// GetPendingDemotions returns all demotion requests that have not reached their quorum yet
+func (repo *InMemoryUserRepository) GetPendingDemotions() []DemotionRequest {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	pending := []DemotionRequest{}
+	for _, request := range repo.Demotions {
+		if request.Status == "pending" {
+			pending = append(pending, request)
+		}
+	}
+	return pending
+}
+
+// VoteDemotion adds an admin's vote to the pending demotion of the target, opening a new request if none exists,
+// and saves the changes to the file. The target is demoted once the request has the votes required by the quorum,
+// counting only the other admins not scheduled for deletion; the last such admin can never be demoted.
+// It returns the number of votes collected so far and the number required.
+func (repo *InMemoryUserRepository) VoteDemotion(target, admin, reason string, quorum int) (int, int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	user, exists := repo.Users[target]
+	if !exists {
+		return 0, 0, fmt.Errorf("User not found")
+	}
+	if user.Role != "admin" {
+		return 0, 0, fmt.Errorf("User is not an admin")
+	}
+	eligible := repo.countRemainingAdmins(target)
+	if eligible == 0 {
+		return 0, 0, fmt.Errorf("Cannot demote the last admin")
+	}
+
+	request, err := repo.findPendingDemotion(target)
+	if err != nil {
+		request = DemotionRequest{
+			ID:          generateID(),
+			Target:      target,
+			RequestedBy: admin,
+			Reason:      reason,
+			CreatedAt:   time.Now(),
+			Status:      "pending",
+		}
+	}
+	for _, voter := range request.Approvals {
+		if voter == admin {
+			return 0, 0, fmt.Errorf("You have already voted to demote this user")
+		}
+	}
+	request.Approvals = append(request.Approvals, admin)
+
+	required := requiredApprovals(quorum, eligible)
+	if len(request.Approvals) >= required {
+		user.Role = "user"
+		repo.Users[target] = user
+		request.Status = "completed"
+		request.CompletedAt = time.Now()
+	}
+	repo.Demotions[request.ID] = request
+	repo.saveToFile() // Persist changes to the file
+	return len(request.Approvals), required, nil
+}
+
+// deleteDemotionsByUser removes the demotion requests targeting a user and withdraws the user's votes from
+// pending demotions of others, dropping requests left without votes; the caller must hold the lock and is responsible for saving
+func (repo *InMemoryUserRepository) deleteDemotionsByUser(username string) {
+	for id, request := range repo.Demotions {
+		if request.Target == username {
+			delete(repo.Demotions, id)
+			continue
+		}
+		if request.Status == "pending" {
+			request.Approvals = withoutVoter(request.Approvals, username)
+			if len(request.Approvals) == 0 {
+				delete(repo.Demotions, id)
+				continue
+			}
+			repo.Demotions[id] = request
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Follow represents one user following another
+type Follow struct {
+	Follower  string
+	Followee  string
+	CreatedAt time.Time
+}
+
+// followKey identifies a follow relationship from follower to followee
+func followKey(follower, followee string) string {
+	return follower + "->" + followee
+}
+
+// --- Follow Methods ---
+
+// FollowUser makes follower follow followee and saves the changes to the file
+func (repo *InMemoryUserRepository) FollowUser(follower, followee string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.Users[followee]; !exists {
+		return fmt.Errorf("User not found")
+	}
+	if follower == followee {
+		return fmt.Errorf("You cannot follow yourself")
+	}
+	key := followKey(follower, followee)
+	if _, exists := repo.Follows[key]; exists {
+		return fmt.Errorf("You already follow this user")
+	}
+	repo.Follows[key] = Follow{Follower: follower, Followee: followee, CreatedAt: time.Now()}
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// UnfollowUser makes follower stop following followee and saves the changes to the file
+func (repo *InMemoryUserRepository) UnfollowUser(follower, followee string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	key := followKey(follower, followee)
+	if _, exists := repo.Follows[key]; !exists {
+		return fmt.Errorf("You do not follow this user")
+	}
+	delete(repo.Follows, key)
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// IsFollowing reports whether follower follows followee
+func (repo *InMemoryUserRepository) IsFollowing(follower, followee string) bool {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	_, exists := repo.Follows[followKey(follower, followee)]
+	return exists
+}
+
+// GetFollowers returns the usernames following a user, in alphabetical order
+func (repo *InMemoryUserRepository) GetFollowers(username string) []string {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	followers := []string{}
+	for _, follow := range repo.Follows {
+		if follow.Followee == username {
+			followers = append(followers, follow.Follower)
+		}
+	}
+	sort.Strings(followers)
+	return followers
+}
+
+// GetFollowing returns the usernames a user follows, in alphabetical order
+func (repo *InMemoryUserRepository) GetFollowing(username string) []string {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return repo.getFollowing(username)
+}
+
+// getFollowing returns the usernames a user follows; the caller must hold the lock
+func (repo *InMemoryUserRepository) getFollowing(username string) []string {
+	following := []string{}
+	for _, follow := range repo.Follows {
+		if follow.Follower == username {
+			following = append(following, follow.Followee)
+		}
+	}
+	sort.Strings(following)
+	return following
+}
+
+// GetTimeline merges the blogs of every user the given user follows, newest first.
+// Each author's blogs come from the per-author index and are merged with a heap, so only
+// the first limit blogs accepted by include are ever taken. The lock is released before
+// include is called, so include may use the repository itself.
+func (repo *InMemoryUserRepository) GetTimeline(username string, include func(Blog) bool, limit int) []Blog {
+	merge := &blogMerge{}
+	repo.mu.RLock()
+	for _, author := range repo.getFollowing(username) {
+		blogs := repo.getBlogsByAuthor(author)
+		if len(blogs) > 0 {
+			merge.streams = append(merge.streams, blogs)
+		}
+	}
+	repo.mu.RUnlock()
+	heap.Init(merge)
+
+	timeline := []Blog{}
+	for merge.Len() > 0 && len(timeline) < limit {
+		blog := merge.streams[0][0]
+		if include(blog) {
+			timeline = append(timeline, blog)
+		}
+		merge.streams[0] = merge.streams[0][1:]
+		if len(merge.streams[0]) == 0 {
+			heap.Pop(merge)
+		} else {
+			heap.Fix(merge, 0)
+		}
+	}
+	return timeline
+}
+
+// deleteFollowsByUser removes every follow relationship involving a user; the caller is responsible for saving
+func (repo *InMemoryUserRepository) deleteFollowsByUser(username string) {
+	for key, follow := range repo.Follows {
+		if follow.Follower == username || follow.Followee == username {
+			delete(repo.Follows, key)
+		}
+	}
+}
+
+// blogMerge is a heap of per-author blog lists, each sorted newest first, ordered by their first blog
+type blogMerge struct {
+	streams [][]Blog
+}
+
+func (m *blogMerge) Len() int { return len(m.streams) }
+
+func (m *blogMerge) Less(i, j int) bool {
+	return blogNewer(m.streams[i][0], m.streams[j][0])
+}
+
+func (m *blogMerge) Swap(i, j int) { m.streams[i], m.streams[j] = m.streams[j], m.streams[i] }
+
+func (m *blogMerge) Push(x any) { m.streams = append(m.streams, x.([]Blog)) }
+
+func (m *blogMerge) Pop() any {
+	last := m.streams[len(m.streams)-1]
+	m.streams = m.streams[:len(m.streams)-1]
+	return last
+}
@@ -0,0 +1,192 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// InviteCode lets people register while registration is invite-only
+type InviteCode struct {
+	Code      string
+	CreatedBy string // Username of the user who created the code
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	MaxUses   int
+	UsedBy    []string  // Usernames of the accounts registered with the code, in order
+	RevokedAt time.Time // Zero unless the code was revoked
+	RevokedBy string
+}
+
+// Usable reports whether the code can still be used to register at the given time, and if not, why
+func (invite InviteCode) Usable(now time.Time) error {
+	switch {
+	case !invite.RevokedAt.IsZero():
+		return fmt.Errorf("Invite code has been revoked")
+	case !now.Before(invite.ExpiresAt):
+		return fmt.Errorf("Invite code has expired")
+	case len(invite.UsedBy) >= invite.MaxUses:
+		return fmt.Errorf("Invite code has been used up")
+	}
+	return nil
+}
+
+// --- Invite Methods ---
+
+// CreateInvite creates an invite code with a random, hard to guess code and saves the changes to the file.
+// Unless quota is negative, the creator may only hand out as many invitations as remain of their quota.
+func (repo *InMemoryUserRepository) CreateInvite(createdBy string, maxUses int, expiresAt time.Time, quota int) (InviteCode, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if quota >= 0 {
+		remaining := repo.remainingInvites(createdBy, quota)
+		if remaining == 0 {
+			return InviteCode{}, fmt.Errorf("You have no invitations left")
+		}
+		if maxUses > remaining {
+			return InviteCode{}, fmt.Errorf("You can invite at most %d more people", remaining)
+		}
+	}
+	code, err := generateInviteCode()
+	if err != nil {
+		return InviteCode{}, err
+	}
+	invite := InviteCode{
+		Code:      code,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+		MaxUses:   maxUses,
+		UsedBy:    []string{},
+	}
+	repo.Invites[code] = invite
+	repo.saveToFile() // Persist changes to the file
+	return invite, nil
+}
+
+// RemainingInvites returns how many more people a user may invite out of their quota
+func (repo *InMemoryUserRepository) RemainingInvites(username string, quota int) int {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return repo.remainingInvites(username, quota)
+}
+
+// remainingInvites returns how many more people a user may invite; the caller must hold the lock.
+// Codes that can still be used count with all their uses; expired and revoked codes only with the uses they got.
+func (repo *InMemoryUserRepository) remainingInvites(username string, quota int) int {
+	now := time.Now()
+	reserved := 0
+	for _, invite := range repo.Invites {
+		if invite.CreatedBy != username {
+			continue
+		}
+		if invite.Usable(now) == nil {
+			reserved += invite.MaxUses
+		} else {
+			reserved += len(invite.UsedBy)
+		}
+	}
+	if reserved >= quota {
+		return 0
+	}
+	return quota - reserved
+}
+
+// FindInvite retrieves an invite code
+func (repo *InMemoryUserRepository) FindInvite(code string) (InviteCode, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	invite, exists := repo.Invites[code]
+	if !exists {
+		return InviteCode{}, fmt.Errorf("Invite code not found")
+	}
+	return invite, nil
+}
+
+// GetInvites returns every invite code, or only those created by the given user if createdBy is not empty, newest first
+func (repo *InMemoryUserRepository) GetInvites(createdBy string) []InviteCode {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	invites := []InviteCode{}
+	for _, invite := range repo.Invites {
+		if createdBy == "" || invite.CreatedBy == createdBy {
+			invites = append(invites, invite)
+		}
+	}
+	sort.Slice(invites, func(i, j int) bool {
+		if !invites[i].CreatedAt.Equal(invites[j].CreatedAt) {
+			return invites[i].CreatedAt.After(invites[j].CreatedAt)
+		}
+		return invites[i].Code < invites[j].Code
+	})
+	return invites
+}
+
+// RevokeInvite stops an invite code from being used again and saves the changes to the file.
+// Accounts already registered with it are not affected.
+func (repo *InMemoryUserRepository) RevokeInvite(code, revokedBy string) (InviteCode, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	invite, exists := repo.Invites[code]
+	if !exists {
+		return InviteCode{}, fmt.Errorf("Invite code not found")
+	}
+	if !invite.RevokedAt.IsZero() {
+		return InviteCode{}, fmt.Errorf("Invite code has already been revoked")
+	}
+	invite.RevokedAt = time.Now()
+	invite.RevokedBy = revokedBy
+	repo.Invites[code] = invite
+	repo.saveToFile() // Persist changes to the file
+	return invite, nil
+}
+
+// CreateUserWithInvite creates a user registered with an invite code and saves the changes to the file.
+// The code is checked and used up in the same step, so a code cannot be used more often than allowed.
+func (repo *InMemoryUserRepository) CreateUserWithInvite(user User, code string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	invite, exists := repo.Invites[code]
+	if !exists {
+		return fmt.Errorf("Invite code not found")
+	}
+	if err := invite.Usable(time.Now()); err != nil {
+		return err
+	}
+	user.InvitedWith = code
+	if err := repo.createUser(user); err != nil {
+		return err
+	}
+	invite.UsedBy = append(append([]string{}, invite.UsedBy...), user.Username)
+	repo.Invites[code] = invite
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// revokeInvitesByUser revokes the codes a user created that could still be used; the caller is responsible for saving
+func (repo *InMemoryUserRepository) revokeInvitesByUser(username string) {
+	now := time.Now()
+	for code, invite := range repo.Invites {
+		if invite.CreatedBy == username && invite.Usable(now) == nil {
+			invite.RevokedAt = now
+			invite.RevokedBy = "system"
+			repo.Invites[code] = invite
+		}
+	}
+}
+
+// generateInviteCode returns a random code of 8 characters; the base32 alphabet has no 0 or 1 to confuse with O and I
+func generateInviteCode() (string, error) {
+	buf := make([]byte, 5)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("Error generating invite code: %s", err)
+	}
+	return base32.StdEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,251 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Message represents a direct message from one user to another
+type Message struct {
+	ID          string
+	From        string
+	To          string
+	Text        string
+	SentAt      time.Time
+	DeliveredAt time.Time // Zero while the message is queued for an offline recipient
+	ReadAt      time.Time // Zero until the recipient opens the conversation
+}
+
+// Block represents one user blocking another from messaging them
+type Block struct {
+	Blocker   string
+	Blocked   string
+	CreatedAt time.Time
+}
+
+// ConversationSummary describes a conversation in a user's inbox
+type ConversationSummary struct {
+	With        string // The other participant
+	LastMessage Message
+	Unread      int // Messages from the other participant the user has not read yet
+}
+
+// blockKey identifies a block from blocker to blocked
+func blockKey(blocker, blocked string) string {
+	return blocker + "->" + blocked
+}
+
+// --- Message Methods ---
+
+// CreateMessage stores a direct message and saves the changes to the file.
+// Messages are refused when the recipient has blocked the sender.
+func (repo *InMemoryUserRepository) CreateMessage(from, to, text string) (Message, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.Users[to]; !exists {
+		return Message{}, fmt.Errorf("User not found")
+	}
+	if from == to {
+		return Message{}, fmt.Errorf("You cannot message yourself")
+	}
+	if _, blocked := repo.Blocks[blockKey(to, from)]; blocked {
+		return Message{}, fmt.Errorf("This user is not accepting messages from you")
+	}
+	message := Message{
+		ID:     generateID(),
+		From:   from,
+		To:     to,
+		Text:   text,
+		SentAt: time.Now(),
+	}
+	repo.Messages[message.ID] = message
+	repo.saveToFile() // Persist changes to the file
+	return message, nil
+}
+
+// MarkMessagesDelivered records that the given messages reached their recipient and saves the changes to the file
+func (repo *InMemoryUserRepository) MarkMessagesDelivered(messageIDs []string) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	now := time.Now()
+	for _, messageID := range messageIDs {
+		if message, exists := repo.Messages[messageID]; exists && message.DeliveredAt.IsZero() {
+			message.DeliveredAt = now
+			repo.Messages[messageID] = message
+		}
+	}
+	repo.saveToFile() // Persist changes to the file
+}
+
+// GetUndeliveredMessages returns the messages queued for a user, oldest first
+func (repo *InMemoryUserRepository) GetUndeliveredMessages(username string) []Message {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	messages := []Message{}
+	for _, message := range repo.Messages {
+		if message.To == username && message.DeliveredAt.IsZero() {
+			messages = append(messages, message)
+		}
+	}
+	sortMessages(messages)
+	return messages
+}
+
+// GetConversation returns every message exchanged between two users, oldest first
+func (repo *InMemoryUserRepository) GetConversation(username, other string) []Message {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	messages := []Message{}
+	for _, message := range repo.Messages {
+		if (message.From == username && message.To == other) || (message.From == other && message.To == username) {
+			messages = append(messages, message)
+		}
+	}
+	sortMessages(messages)
+	return messages
+}
+
+// MarkConversationRead marks every message from other to username as read and saves the changes to the file
+func (repo *InMemoryUserRepository) MarkConversationRead(username, other string) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	now := time.Now()
+	changed := false
+	for messageID, message := range repo.Messages {
+		if message.From == other && message.To == username && message.ReadAt.IsZero() {
+			if message.DeliveredAt.IsZero() {
+				message.DeliveredAt = now
+			}
+			message.ReadAt = now
+			repo.Messages[messageID] = message
+			changed = true
+		}
+	}
+	if changed {
+		repo.saveToFile() // Persist changes to the file
+	}
+}
+
+// GetInbox returns a summary of every conversation a user takes part in, most recent first
+func (repo *InMemoryUserRepository) GetInbox(username string) []ConversationSummary {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	conversations := make(map[string]*ConversationSummary)
+	for _, message := range repo.Messages {
+		var other string
+		switch username {
+		case message.To:
+			other = message.From
+		case message.From:
+			other = message.To
+		default:
+			continue
+		}
+		summary, exists := conversations[other]
+		if !exists {
+			summary = &ConversationSummary{With: other}
+			conversations[other] = summary
+		}
+		if message.SentAt.After(summary.LastMessage.SentAt) {
+			summary.LastMessage = message
+		}
+		if message.To == username && message.ReadAt.IsZero() {
+			summary.Unread++
+		}
+	}
+
+	inbox := []ConversationSummary{}
+	for _, summary := range conversations {
+		inbox = append(inbox, *summary)
+	}
+	sort.Slice(inbox, func(i, j int) bool {
+		return inbox[i].LastMessage.SentAt.After(inbox[j].LastMessage.SentAt)
+	})
+	return inbox
+}
+
+// --- Block Methods ---
+
+// BlockUser stops blocked from messaging blocker and saves the changes to the file
+func (repo *InMemoryUserRepository) BlockUser(blocker, blocked string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.Users[blocked]; !exists {
+		return fmt.Errorf("User not found")
+	}
+	if blocker == blocked {
+		return fmt.Errorf("You cannot block yourself")
+	}
+	key := blockKey(blocker, blocked)
+	if _, exists := repo.Blocks[key]; exists {
+		return fmt.Errorf("User is already blocked")
+	}
+	repo.Blocks[key] = Block{Blocker: blocker, Blocked: blocked, CreatedAt: time.Now()}
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// UnblockUser lifts a block and saves the changes to the file
+func (repo *InMemoryUserRepository) UnblockUser(blocker, blocked string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	key := blockKey(blocker, blocked)
+	if _, exists := repo.Blocks[key]; !exists {
+		return fmt.Errorf("User is not blocked")
+	}
+	delete(repo.Blocks, key)
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// IsBlocked reports whether blocker has blocked blocked
+func (repo *InMemoryUserRepository) IsBlocked(blocker, blocked string) bool {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	_, exists := repo.Blocks[blockKey(blocker, blocked)]
+	return exists
+}
+
+// GetBlockedUsers returns the usernames a user has blocked, in alphabetical order
+func (repo *InMemoryUserRepository) GetBlockedUsers(blocker string) []string {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	blocked := []string{}
+	for _, block := range repo.Blocks {
+		if block.Blocker == blocker {
+			blocked = append(blocked, block.Blocked)
+		}
+	}
+	sort.Strings(blocked)
+	return blocked
+}
+
+// deleteBlocksByUser removes every block involving a user; the caller is responsible for saving
+func (repo *InMemoryUserRepository) deleteBlocksByUser(username string) {
+	for key, block := range repo.Blocks {
+		if block.Blocker == username || block.Blocked == username {
+			delete(repo.Blocks, key)
+		}
+	}
+}
+
+// sortMessages orders messages by the time they were sent, oldest first
+func sortMessages(messages []Message) {
+	sort.Slice(messages, func(i, j int) bool {
+		if !messages[i].SentAt.Equal(messages[j].SentAt) {
+			return messages[i].SentAt.Before(messages[j].SentAt)
+		}
+		return messages[i].ID < messages[j].ID
+	})
+}
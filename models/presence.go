@@ -0,0 +1,38 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// --- Presence Methods ---
+
+// RecordLastSeen sets the time the user was last connected and saves the changes to the file
+func (repo *InMemoryUserRepository) RecordLastSeen(username string, seen time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	user, exists := repo.Users[username]
+	if !exists {
+		return fmt.Errorf("User not found")
+	}
+	user.LastSeen = seen
+	repo.Users[username] = user
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// SetPresenceHidden sets whether the user appears offline to others and saves the changes to the file
+func (repo *InMemoryUserRepository) SetPresenceHidden(username string, hidden bool) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	user, exists := repo.Users[username]
+	if !exists {
+		return fmt.Errorf("User not found")
+	}
+	user.HidePresence = hidden
+	repo.Users[username] = user
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
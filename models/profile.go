@@ -0,0 +1,262 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+)
+
+// Year is a calendar year, zero when unknown
+type Year int
+
+// String formats the year, or returns an empty string when it is unknown
+func (year Year) String() string {
+	if year == 0 {
+		return ""
+	}
+	return strconv.Itoa(int(year))
+}
+
+// UnmarshalJSON reads a year stored as a number, or as the free-form text older data files used.
+// Text that is not a whole number is treated as an unknown year.
+func (year *Year) UnmarshalJSON(data []byte) error {
+	var number int
+	if err := json.Unmarshal(data, &number); err == nil {
+		*year = Year(number)
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	number, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil {
+		number = 0
+	}
+	*year = Year(number)
+	return nil
+}
+
+// ProfileField describes one field of the profile schema
+type ProfileField struct {
+	Key        string // Name used in commands such as "set-profile"
+	Label      string // Name shown to users
+	Type       string // "text", "number" or "year"
+	Required   bool   // Whether the field may be left empty
+	MaxLength  int    // Maximum length of a text value in characters, 0 for the default limit
+	Pattern    string // Regular expression a text value must match in full, empty to accept any text
+	Min        int    // Smallest accepted number or year; 0 for a year means 1900
+	Max        int    // Largest accepted number or year; 0 for a year means the current year
+	Visibility string // Who may see the field by default: "public", "followers" or "private"
+	BuiltIn    bool   // Built-in fields are stored in their own models.User field and cannot be removed
+}
+
+// builtInProfileFields lists the fields every profile has, in display order
+var builtInProfileFields = []ProfileField{
+	{Key: "name", Label: "Name", Type: "text", Visibility: "public", BuiltIn: true},
+	{Key: "surname", Label: "Surname", Type: "text", Visibility: "public", BuiltIn: true},
+	{Key: "fav-animal", Label: "Favorite Animal", Type: "text", Visibility: "public", BuiltIn: true},
+	{Key: "fav-movie", Label: "Favorite Movie", Type: "text", Visibility: "public", BuiltIn: true},
+	{Key: "year-of-birth", Label: "Year of Birth", Type: "year", Visibility: "public", BuiltIn: true},
+	{Key: "city", Label: "City of Birth", Type: "text", Visibility: "public", BuiltIn: true},
+	{Key: "football-team", Label: "Football Team", Type: "text", Visibility: "public", BuiltIn: true},
+}
+
+// ProfileField returns the value of a profile field as text, or an empty string if it is not set
+func (user User) ProfileField(key string) string {
+	switch key {
+	case "name":
+		return user.Name
+	case "surname":
+		return user.Surname
+	case "fav-animal":
+		return user.FavAnimal
+	case "fav-movie":
+		return user.FavMovie
+	case "year-of-birth":
+		return user.YearOfBirth.String()
+	case "city":
+		return user.CityOfBirth
+	case "football-team":
+		return user.FootballTeam
+	}
+	return user.CustomProfile[key]
+}
+
+// SetProfileField changes the value of a profile field given as text; an empty value clears it.
+// Keys other than the built-in fields are stored in CustomProfile.
+func (user *User) SetProfileField(key, value string) error {
+	switch key {
+	case "name":
+		user.Name = value
+	case "surname":
+		user.Surname = value
+	case "fav-animal":
+		user.FavAnimal = value
+	case "fav-movie":
+		user.FavMovie = value
+	case "year-of-birth":
+		if value == "" {
+			user.YearOfBirth = 0
+			break
+		}
+		year, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("Year of birth must be a whole number")
+		}
+		user.YearOfBirth = Year(year)
+	case "city":
+		user.CityOfBirth = value
+	case "football-team":
+		user.FootballTeam = value
+	default:
+		if value == "" {
+			delete(user.CustomProfile, key)
+			break
+		}
+		if user.CustomProfile == nil {
+			user.CustomProfile = make(map[string]string)
+		}
+		user.CustomProfile[key] = value
+	}
+	return nil
+}
+
+// FieldVisibility returns who may see a profile field of the user: their own choice, or else the schema default
+func (user User) FieldVisibility(field ProfileField) string {
+	if visibility, set := user.ProfileVisibility[field.Key]; set {
+		return visibility
+	}
+	return field.Visibility
+}
+
+// --- Profile Methods ---
+
+// GetProfileSchema returns the built-in profile fields followed by the custom ones, in display order
+func (repo *InMemoryUserRepository) GetProfileSchema() []ProfileField {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	return append(append([]ProfileField{}, builtInProfileFields...), repo.ProfileSchema...)
+}
+
+// findProfileField retrieves a field of the profile schema by key; the caller must hold the lock
+func (repo *InMemoryUserRepository) findProfileField(key string) (ProfileField, bool) {
+	for _, field := range builtInProfileFields {
+		if field.Key == key {
+			return field, true
+		}
+	}
+	for _, field := range repo.ProfileSchema {
+		if field.Key == key {
+			return field, true
+		}
+	}
+	return ProfileField{}, false
+}
+
+// AddProfileField appends a custom field to the profile schema and saves the changes to the file
+func (repo *InMemoryUserRepository) AddProfileField(field ProfileField) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.findProfileField(field.Key); exists {
+		return fmt.Errorf("Profile field already exists")
+	}
+	field.BuiltIn = false
+	repo.ProfileSchema = append(repo.ProfileSchema, field)
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// RemoveProfileField removes a custom field from the profile schema, together with every user's value for it,
+// and saves the changes to the file
+func (repo *InMemoryUserRepository) RemoveProfileField(key string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	field, exists := repo.findProfileField(key)
+	if !exists {
+		return fmt.Errorf("Profile field not found")
+	}
+	if field.BuiltIn {
+		return fmt.Errorf("Built-in profile fields cannot be removed")
+	}
+	remaining := []ProfileField{}
+	for _, existing := range repo.ProfileSchema {
+		if existing.Key != key {
+			remaining = append(remaining, existing)
+		}
+	}
+	repo.ProfileSchema = remaining
+	for username, user := range repo.Users {
+		// Copy the maps so that copies of the user handed out earlier are not modified
+		user.CustomProfile = withoutKey(user.CustomProfile, key)
+		user.ProfileVisibility = withoutKey(user.ProfileVisibility, key)
+		repo.Users[username] = user
+	}
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// UpdateProfileFields changes only the given profile fields of a user and saves the changes to the file.
+// Nothing is changed if any of the fields is not part of the profile schema.
+func (repo *InMemoryUserRepository) UpdateProfileFields(username string, changes map[string]string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	user, exists := repo.Users[username]
+	if !exists {
+		return fmt.Errorf("User not found")
+	}
+	for key := range changes {
+		if _, known := repo.findProfileField(key); !known {
+			return fmt.Errorf("Unknown profile field: %s", key)
+		}
+	}
+	// Copy the custom values so that a failed update leaves the stored map untouched
+	user.CustomProfile = maps.Clone(user.CustomProfile)
+	for key, value := range changes {
+		if err := user.SetProfileField(key, value); err != nil {
+			return err
+		}
+	}
+	repo.Users[username] = user
+	repo.indexUser(user)
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// SetProfileVisibility sets who may see one of the user's profile fields and saves the changes to the file
+func (repo *InMemoryUserRepository) SetProfileVisibility(username, key, visibility string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	user, exists := repo.Users[username]
+	if !exists {
+		return fmt.Errorf("User not found")
+	}
+	if _, known := repo.findProfileField(key); !known {
+		return fmt.Errorf("Unknown profile field: %s", key)
+	}
+	// Copy the settings so that copies of the user handed out earlier are not modified
+	user.ProfileVisibility = withoutKey(user.ProfileVisibility, key)
+	user.ProfileVisibility[key] = visibility
+	repo.Users[username] = user
+	repo.indexUser(user)
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// withoutKey returns a copy of the map without the given key
+func withoutKey(values map[string]string, key string) map[string]string {
+	copied := make(map[string]string)
+	for existing, value := range values {
+		if existing != key {
+			copied[existing] = value
+		}
+	}
+	return copied
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// --- Publishing Methods ---
+
+// SetBlogStatus moves a user's own unpublished blog to a new status and saves the changes to the file.
+// Published blogs stay published.
+func (repo *InMemoryUserRepository) SetBlogStatus(username, blogID, status string, publishAt time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	blog, exists := repo.Blogs[blogID]
+	if !exists {
+		return fmt.Errorf("Blog not found")
+	}
+	if blog.Author != username {
+		return fmt.Errorf("You are not the author of this blog")
+	}
+	if blog.Status == "published" {
+		return fmt.Errorf("Blog is already published")
+	}
+
+	blog.PublishAt = time.Time{}
+	switch status {
+	case "published":
+		blog.PublishedAt = time.Now()
+	case "scheduled":
+		blog.PublishAt = publishAt
+	case "draft":
+	default:
+		return fmt.Errorf("Invalid blog status: %s", status)
+	}
+	blog.Status = status
+	repo.Blogs[blogID] = blog
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// SetBlogVisibility changes who can read a user's own blog and saves the changes to the file
+func (repo *InMemoryUserRepository) SetBlogVisibility(username, blogID, visibility string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	blog, exists := repo.Blogs[blogID]
+	if !exists {
+		return fmt.Errorf("Blog not found")
+	}
+	if blog.Author != username {
+		return fmt.Errorf("You are not the author of this blog")
+	}
+	blog.Visibility = visibility
+	repo.Blogs[blogID] = blog
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// PublishDueBlogs publishes every scheduled blog whose publish time has passed and saves the changes to the file.
+// It returns the blogs that were published.
+func (repo *InMemoryUserRepository) PublishDueBlogs(now time.Time) []Blog {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	published := []Blog{}
+	for blogID, blog := range repo.Blogs {
+		if blog.Status == "scheduled" && !blog.PublishAt.After(now) {
+			blog.Status = "published"
+			blog.PublishedAt = now
+			repo.Blogs[blogID] = blog
+			published = append(published, blog)
+		}
+	}
+	if len(published) > 0 {
+		repo.saveToFile() // Persist changes to the file
+	}
+	return published
+}
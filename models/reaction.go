@@ -0,0 +1,115 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReactionKinds lists the reactions a user can leave on a blog post
+var ReactionKinds = []string{"like", "love", "laugh", "wow", "sad", "angry"}
+
+// Reaction represents a single user's reaction to a blog post; each user has at most one per post
+type Reaction struct {
+	BlogID    string
+	Username  string
+	Kind      string // One of ReactionKinds
+	CreatedAt time.Time
+}
+
+// reactionKey identifies the reaction of a user on a blog
+func reactionKey(blogID, username string) string {
+	return blogID + "/" + username
+}
+
+// --- Reaction Methods ---
+
+// SetReaction records a user's reaction to a blog, replacing any previous one, and saves the changes to the file
+func (repo *InMemoryUserRepository) SetReaction(blogID, username, kind string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.Blogs[blogID]; !exists {
+		return fmt.Errorf("Blog not found")
+	}
+	valid := false
+	for _, known := range ReactionKinds {
+		if kind == known {
+			valid = true
+		}
+	}
+	if !valid {
+		return fmt.Errorf("Unknown reaction: %s", kind)
+	}
+	repo.Reactions[reactionKey(blogID, username)] = Reaction{
+		BlogID:    blogID,
+		Username:  username,
+		Kind:      kind,
+		CreatedAt: time.Now(),
+	}
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// RemoveReaction removes a user's reaction from a blog and saves the changes to the file
+func (repo *InMemoryUserRepository) RemoveReaction(blogID, username string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	key := reactionKey(blogID, username)
+	if _, exists := repo.Reactions[key]; !exists {
+		return fmt.Errorf("You have not reacted to this blog")
+	}
+	delete(repo.Reactions, key)
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// CountReactions returns the number of reactions of each kind on a blog
+func (repo *InMemoryUserRepository) CountReactions(blogID string) map[string]int {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, reaction := range repo.Reactions {
+		if reaction.BlogID == blogID {
+			counts[reaction.Kind]++
+		}
+	}
+	return counts
+}
+
+// GetReactedBlogs returns the blogs a user reacted to with the given kind, newest first
+func (repo *InMemoryUserRepository) GetReactedBlogs(username, kind string) []Blog {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	blogs := []Blog{}
+	for _, reaction := range repo.Reactions {
+		if reaction.Username != username || reaction.Kind != kind {
+			continue
+		}
+		if blog, exists := repo.Blogs[reaction.BlogID]; exists {
+			blogs = append(blogs, blog)
+		}
+	}
+	sortBlogs(blogs)
+	return blogs
+}
+
+// deleteReactionsByBlog removes every reaction on a blog; the caller is responsible for saving
+func (repo *InMemoryUserRepository) deleteReactionsByBlog(blogID string) {
+	for key, reaction := range repo.Reactions {
+		if reaction.BlogID == blogID {
+			delete(repo.Reactions, key)
+		}
+	}
+}
+
+// deleteReactionsByUser removes every reaction left by a user; the caller is responsible for saving
+func (repo *InMemoryUserRepository) deleteReactionsByUser(username string) {
+	for key, reaction := range repo.Reactions {
+		if reaction.Username == username {
+			delete(repo.Reactions, key)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+)
+
+// --- Registration Approval Methods ---
+
+// GetPendingRegistrations returns the accounts waiting for an admin to approve them, oldest first
+func (repo *InMemoryUserRepository) GetPendingRegistrations() []User {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	pending := []User{}
+	for _, user := range repo.Users {
+		if user.Status == "pending" {
+			pending = append(pending, user)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		if !pending[i].RegisteredAt.Equal(pending[j].RegisteredAt) {
+			return pending[i].RegisteredAt.Before(pending[j].RegisteredAt)
+		}
+		return pending[i].Username < pending[j].Username
+	})
+	return pending
+}
+
+// ApproveRegistration lets a pending account log in and saves the changes to the file
+func (repo *InMemoryUserRepository) ApproveRegistration(username string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	user, exists := repo.Users[username]
+	if !exists || user.Status != "pending" {
+		return fmt.Errorf("No pending registration for user: %s", username)
+	}
+	user.Status = "approved"
+	repo.Users[username] = user
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// DenyRegistration deletes a pending account, freeing its username, and saves the changes to the file
+func (repo *InMemoryUserRepository) DenyRegistration(username string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	user, exists := repo.Users[username]
+	if !exists || user.Status != "pending" {
+		return fmt.Errorf("No pending registration for user: %s", username)
+	}
+	repo.deleteUser(username)
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
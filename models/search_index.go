@@ -0,0 +1,196 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SearchResult is a single ranked match returned by Search
+type SearchResult struct {
+	Kind  string // "blog" or "user"
+	ID    string // Blog ID or username
+	Score float64
+}
+
+// searchIndex is an inverted index over blog titles and bodies and selected profile fields.
+// Documents are keyed as "blog:<id>" or "user:<username>" and every term keeps the positions
+// it occurs at so that phrase queries can be matched.
+type searchIndex struct {
+	postings   map[string]map[string][]int // term -> document key -> positions
+	docTerms   map[string][]string         // document key -> distinct terms, used to remove the document again
+	titleTerms map[string]map[string]bool  // document key -> terms that appear in the title or name
+}
+
+// newSearchIndex creates an empty search index
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		postings:   make(map[string]map[string][]int),
+		docTerms:   make(map[string][]string),
+		titleTerms: make(map[string]map[string]bool),
+	}
+}
+
+// tokenize splits text into lowercase terms made of letters and digits
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// add indexes a document made of a title and further fields, replacing any earlier version of it.
+// Fields are separated by a position gap so that phrases never match across them.
+func (index *searchIndex) add(key, title string, fields ...string) {
+	index.remove(key)
+
+	position := 0
+	titles := make(map[string]bool)
+	for i, field := range append([]string{title}, fields...) {
+		for _, term := range tokenize(field) {
+			if index.postings[term] == nil {
+				index.postings[term] = make(map[string][]int)
+			}
+			if len(index.postings[term][key]) == 0 {
+				index.docTerms[key] = append(index.docTerms[key], term)
+			}
+			index.postings[term][key] = append(index.postings[term][key], position)
+			if i == 0 {
+				titles[term] = true
+			}
+			position++
+		}
+		position++
+	}
+	index.titleTerms[key] = titles
+}
+
+// remove drops a document from the index
+func (index *searchIndex) remove(key string) {
+	for _, term := range index.docTerms[key] {
+		delete(index.postings[term], key)
+		if len(index.postings[term]) == 0 {
+			delete(index.postings, term)
+		}
+	}
+	delete(index.docTerms, key)
+	delete(index.titleTerms, key)
+}
+
+// search returns the keys of documents matching every term and phrase of the query, best match first.
+// Words in double quotes form a phrase that must appear in that exact order.
+// Scores are the TF-IDF of the matched terms, doubled for terms found in the title.
+func (index *searchIndex) search(query string) []SearchResult {
+	phrases := parseQuery(query)
+	if len(phrases) == 0 {
+		return []SearchResult{}
+	}
+
+	var candidates map[string]bool
+	for _, phrase := range phrases {
+		matches := make(map[string]bool)
+		for key := range index.postings[phrase[0]] {
+			if (candidates == nil || candidates[key]) && index.matchesPhrase(key, phrase) {
+				matches[key] = true
+			}
+		}
+		candidates = matches
+		if len(candidates) == 0 {
+			return []SearchResult{}
+		}
+	}
+
+	documents := float64(len(index.docTerms))
+	results := []SearchResult{}
+	for key := range candidates {
+		score := 0.0
+		for _, phrase := range phrases {
+			for _, term := range phrase {
+				idf := math.Log(1 + documents/float64(len(index.postings[term])))
+				weight := float64(len(index.postings[term][key])) * idf
+				if index.titleTerms[key][term] {
+					weight *= 2
+				}
+				score += weight
+			}
+		}
+		kind, id, _ := strings.Cut(key, ":")
+		results = append(results, SearchResult{Kind: kind, ID: id, Score: score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Kind+results[i].ID < results[j].Kind+results[j].ID
+	})
+	return results
+}
+
+// matchesPhrase reports whether the terms of a phrase appear consecutively in a document
+func (index *searchIndex) matchesPhrase(key string, phrase []string) bool {
+	for _, start := range index.postings[phrase[0]][key] {
+		matched := true
+		for offset, term := range phrase[1:] {
+			if !containsPosition(index.postings[term][key], start+offset+1) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// containsPosition reports whether a sorted list of positions contains the given position
+func containsPosition(positions []int, position int) bool {
+	i := sort.SearchInts(positions, position)
+	return i < len(positions) && positions[i] == position
+}
+
+// parseQuery splits a query into phrases. Quoted text becomes one multi-term phrase,
+// every other word becomes a phrase of its own.
+func parseQuery(query string) [][]string {
+	phrases := [][]string{}
+	for i, part := range strings.Split(query, "\"") {
+		terms := tokenize(part)
+		if i%2 == 1 {
+			if len(terms) > 0 {
+				phrases = append(phrases, terms)
+			}
+			continue
+		}
+		for _, term := range terms {
+			phrases = append(phrases, []string{term})
+		}
+	}
+	return phrases
+}
+
+// --- Search Methods ---
+
+// Search returns every blog and user matching the query, best match first
+func (repo *InMemoryUserRepository) Search(query string) []SearchResult {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	return repo.searchIndex.search(query)
+}
+
+// indexBlog adds or refreshes a blog in the search index
+func (repo *InMemoryUserRepository) indexBlog(blog Blog) {
+	repo.searchIndex.add("blog:"+blog.ID, blog.Title, blog.Text)
+}
+
+// indexUser adds or refreshes a user's searchable profile fields in the search index.
+// Fields the user has not made public are left out so that searches cannot reveal them.
+func (repo *InMemoryUserRepository) indexUser(user User) {
+	public := func(key string) string {
+		if visibility, set := user.ProfileVisibility[key]; set && visibility != "public" {
+			return ""
+		}
+		return user.ProfileField(key)
+	}
+	repo.searchIndex.add("user:"+user.Username, user.Username+" "+public("name")+" "+public("surname"), public("city"), public("football-team"))
+}
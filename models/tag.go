@@ -0,0 +1,79 @@
+package models
+
+import "fmt"
+
+// TagCount pairs a tag with the number of blogs carrying it
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+// --- Tag Methods ---
+
+// SetBlogTags replaces the tags of a user's own blog and saves the changes to the file.
+// Tags are expected to be normalized by the caller.
+func (repo *InMemoryUserRepository) SetBlogTags(username, blogID string, tags []string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	blog, exists := repo.Blogs[blogID]
+	if !exists {
+		return fmt.Errorf("Blog not found")
+	}
+	if blog.Author != username {
+		return fmt.Errorf("You are not the author of this blog")
+	}
+	repo.unindexTags(blog)
+	blog.Tags = tags
+	repo.Blogs[blogID] = blog
+	repo.indexTags(blog)
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// GetBlogsByTag returns the blogs carrying a tag, newest first, using the tag index
+func (repo *InMemoryUserRepository) GetBlogsByTag(tag string) []Blog {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	blogs := []Blog{}
+	for blogID := range repo.tagIndex[tag] {
+		blogs = append(blogs, repo.Blogs[blogID])
+	}
+	sortBlogs(blogs)
+	return blogs
+}
+
+// GetTaggedBlogs returns the blogs carrying each tag, using the tag index
+func (repo *InMemoryUserRepository) GetTaggedBlogs() map[string][]Blog {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	tagged := make(map[string][]Blog, len(repo.tagIndex))
+	for tag, blogIDs := range repo.tagIndex {
+		for blogID := range blogIDs {
+			tagged[tag] = append(tagged[tag], repo.Blogs[blogID])
+		}
+	}
+	return tagged
+}
+
+// indexTags adds a blog to the tag index under each of its tags
+func (repo *InMemoryUserRepository) indexTags(blog Blog) {
+	for _, tag := range blog.Tags {
+		if repo.tagIndex[tag] == nil {
+			repo.tagIndex[tag] = make(map[string]struct{})
+		}
+		repo.tagIndex[tag][blog.ID] = struct{}{}
+	}
+}
+
+// unindexTags removes a blog from the tag index, dropping tags that no longer have any blogs
+func (repo *InMemoryUserRepository) unindexTags(blog Blog) {
+	for _, tag := range blog.Tags {
+		delete(repo.tagIndex[tag], blog.ID)
+		if len(repo.tagIndex[tag]) == 0 {
+			delete(repo.tagIndex, tag)
+		}
+	}
+}
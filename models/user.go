@@ -4,50 +4,128 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
 )
 
+// schemaVersion is the current version of the persisted data layout, used to run one-off migrations on load
+const schemaVersion = 6
+
 // User struct represents a user with various profile attributes
 type User struct {
-	Username     string
-	Password     string
-	Role         string // "user" or "admin"
-	Status       string // "approved" or "pending"
-	Name         string
-	Surname      string
-	FavAnimal    string
-	FavMovie     string
-	YearOfBirth  string
-	CityOfBirth  string
-	FootballTeam string
+	Username          string
+	Password          string
+	Role              string // "user" or "admin"
+	Status            string // Account status, "approved" once the account may be used, "pending" while awaiting approval
+	Name              string
+	Surname           string
+	FavAnimal         string
+	FavMovie          string
+	YearOfBirth       Year
+	CityOfBirth       string
+	FootballTeam      string
+	CustomProfile     map[string]string // Values of the fields admins added to the profile schema, keyed by field key
+	ProfileVisibility map[string]string // Per-field visibility chosen by the user, overriding the schema default
+	LastSeen          time.Time         // When the user was last connected
+	HidePresence      bool              // Whether the user appears offline to others
+	DeleteAfter       time.Time         // When a requested account deletion takes effect, zero if none was requested
+	RegisteredAt      time.Time         // When the account was created, zero for accounts older than this field
+	InvitedWith       string            // Invite code the account was registered with, if any
 }
 
 // Blog struct represents a blog post with an associated author (user)
 type Blog struct {
-	ID     string // Unique ID for the blog
-	Author string // Username of the blog's author
-	Title  string
-	Text   string
+	ID          string // Unique ID for the blog
+	Author      string // Username of the blog's author
+	Title       string
+	Text        string
+	Tags        []string  // Normalized tags, see services.normalizeTags
+	Status      string    // "draft", "scheduled" or "published"
+	Visibility  string    // "public", "followers" or "private"
+	PublishAt   time.Time // When a scheduled blog is due to be published
+	PublishedAt time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Revisions   []BlogRevision // Previous versions of the blog, oldest first
+}
+
+// BlogRevision represents an earlier version of a blog's title and text
+type BlogRevision struct {
+	Title      string
+	Text       string
+	ReplacedAt time.Time // When this version was superseded by an edit or restore
 }
 
 // InMemoryUserRepository represents the in-memory database for users and blogs with file persistence
 type InMemoryUserRepository struct {
-	Users map[string]User
-	Blogs map[string]Blog
-	file  string // file path to persist data
+	Version       int // Schema version of the persisted data
+	Users         map[string]User
+	Blogs         map[string]Blog
+	Applications  map[string]AdminApplication
+	Demotions     map[string]DemotionRequest
+	Comments      map[string]Comment
+	Reactions     map[string]Reaction // Keyed by blog ID and username
+	Follows       map[string]Follow   // Keyed by follower and followee
+	AuditLog      map[string]AuditEntry
+	Messages      map[string]Message
+	Blocks        map[string]Block // Keyed by blocker and blocked
+	ChatRooms     map[string]ChatRoom
+	Invites       map[string]InviteCode // Keyed by code
+	ProfileSchema []ProfileField        // Custom profile fields added by admins, in display order
+	file          string                // file path to persist data
+
+	mu sync.RWMutex // guards the data above and the indexes below; the scheduler and every connection share the repository
+
+	// Lookup indexes rebuilt from the data above on load; they are not persisted
+	tagIndex    map[string]map[string]struct{} // tag -> set of blog IDs
+	authorIndex map[string]map[string]struct{} // author -> set of blog IDs
+	searchIndex *searchIndex                   // full-text index over blogs and profiles
+
+	usernameIndex map[string][]string // canonical username -> usernames, used for case-insensitive lookups
 }
 
 // NewInMemoryUserRepository initializes a new repository with in-memory maps for users and blogs, and loads data from a file
 func NewInMemoryUserRepository(file string) *InMemoryUserRepository {
 	repo := &InMemoryUserRepository{
-		Users: make(map[string]User),
-		Blogs: make(map[string]Blog),
-		file:  file,
+		Version:      schemaVersion,
+		Users:        make(map[string]User),
+		Blogs:        make(map[string]Blog),
+		Applications: make(map[string]AdminApplication),
+		Demotions:    make(map[string]DemotionRequest),
+		Comments:     make(map[string]Comment),
+		Reactions:    make(map[string]Reaction),
+		Follows:      make(map[string]Follow),
+		AuditLog:     make(map[string]AuditEntry),
+		Messages:     make(map[string]Message),
+		Blocks:       make(map[string]Block),
+		ChatRooms:    make(map[string]ChatRoom),
+		Invites:      make(map[string]InviteCode),
+		file:         file,
 	}
 	repo.loadFromFile()
+	repo.buildIndexes()
 	return repo
 }
 
+// buildIndexes rebuilds the in-memory lookup indexes from the loaded data
+func (repo *InMemoryUserRepository) buildIndexes() {
+	repo.tagIndex = make(map[string]map[string]struct{})
+	repo.authorIndex = make(map[string]map[string]struct{})
+	repo.searchIndex = newSearchIndex()
+	repo.usernameIndex = make(map[string][]string)
+	for _, blog := range repo.Blogs {
+		repo.indexAuthor(blog)
+		repo.indexTags(blog)
+		repo.indexBlog(blog)
+	}
+	for _, user := range repo.Users {
+		repo.indexUser(user)
+		repo.indexUsername(user.Username)
+	}
+}
+
 // loadFromFile loads the users and blogs from the specified JSON file
 func (repo *InMemoryUserRepository) loadFromFile() {
 	fileData, err := ioutil.ReadFile(repo.file)
@@ -56,10 +134,87 @@ func (repo *InMemoryUserRepository) loadFromFile() {
 		fmt.Println("No data file found, starting fresh.")
 		return
 	}
+	// Files written before versioning have no Version field and are treated as version 0
+	repo.Version = 0
 	err = json.Unmarshal(fileData, repo)
 	if err != nil {
 		fmt.Printf("Error reading data from file: %s\n", err)
+		return
+	}
+	repo.migrate()
+}
+
+// migrate upgrades data loaded from an older file layout to the current schema version
+func (repo *InMemoryUserRepository) migrate() {
+	if repo.Version >= schemaVersion {
+		return
 	}
+
+	if repo.Version < 1 {
+		// Admin applications used to be stored on the user record as Role "admin" with Status "pending",
+		// and "pending" was also the status given to every new registration.
+		for username, user := range repo.Users {
+			if user.Role == "admin" && user.Status == "pending" {
+				application := AdminApplication{
+					ID:        generateID(),
+					Applicant: username,
+					CreatedAt: time.Now(),
+					Decision:  "pending",
+				}
+				repo.Applications[application.ID] = application
+				user.Role = "user"
+			}
+			if user.Status == "pending" {
+				user.Status = "approved"
+			}
+			repo.Users[username] = user
+		}
+	}
+
+	if repo.Version < 2 {
+		// Blogs had no timestamps; their IDs were generated from the creation time in nanoseconds
+		for blogID, blog := range repo.Blogs {
+			if nanos, err := strconv.ParseInt(blog.ID, 10, 64); err == nil && blog.CreatedAt.IsZero() {
+				blog.CreatedAt = time.Unix(0, nanos)
+				blog.UpdatedAt = blog.CreatedAt
+				repo.Blogs[blogID] = blog
+			}
+		}
+	}
+
+	if repo.Version < 3 {
+		// Every blog was published immediately before drafts and scheduling existed
+		for blogID, blog := range repo.Blogs {
+			if blog.Status == "" {
+				blog.Status = "published"
+				blog.PublishedAt = blog.CreatedAt
+				repo.Blogs[blogID] = blog
+			}
+		}
+	}
+
+	if repo.Version < 4 {
+		// All blogs were public before per-post visibility existed
+		for blogID, blog := range repo.Blogs {
+			if blog.Visibility == "" {
+				blog.Visibility = "public"
+				repo.Blogs[blogID] = blog
+			}
+		}
+	}
+
+	// Version 5 stores YearOfBirth as a number instead of free-form text. Year.UnmarshalJSON already read
+	// the old text form while loading, so saving the file below is all the conversion needs.
+
+	if repo.Version < 6 {
+		// Usernames used to be case-sensitive, so the data may hold look-alike identities such as
+		// "Bero" and "bero". They cannot be merged automatically; report them for an admin to resolve.
+		repo.reportUsernameCollisions()
+	}
+
+	fmt.Printf("Migrated data file from version %d to %d.\n", repo.Version, schemaVersion)
+	repo.Version = schemaVersion
+	repo.saveToFile()
 }
 
 // saveToFile writes the current users and blogs data to the specified JSON file
@@ -79,16 +234,54 @@ func (repo *InMemoryUserRepository) saveToFile() {
 
 // CreateUser adds a new user to the repository and saves the changes to the file
 func (repo *InMemoryUserRepository) CreateUser(user User) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if err := repo.createUser(user); err != nil {
+		return err
+	}
+	repo.saveToFile() // Persist changes to the file
+	return nil
+}
+
+// createUser adds a user unless the name or its canonical form is taken; the caller is responsible for saving
+func (repo *InMemoryUserRepository) createUser(user User) error {
 	if _, exists := repo.Users[user.Username]; exists {
 		return fmt.Errorf("User already exists")
 	}
+	if len(repo.usernameIndex[CanonicalUsername(user.Username)]) > 0 {
+		return fmt.Errorf("User already exists")
+	}
 	repo.Users[user.Username] = user
+	repo.indexUser(user)
+	repo.indexUsername(user.Username)
+	return nil
+}
+
+// ReplacePasswordHash swaps a user's password hash for a new one and saves the changes to the file.
+// It fails if the stored hash is no longer oldHash, so a password changed in the meantime is never overwritten.
+func (repo *InMemoryUserRepository) ReplacePasswordHash(username, oldHash, newHash string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	user, exists := repo.Users[username]
+	if !exists {
+		return fmt.Errorf("User not found")
+	}
+	if user.Password != oldHash {
+		return fmt.Errorf("Password has changed")
+	}
+	user.Password = newHash
+	repo.Users[username] = user
 	repo.saveToFile() // Persist changes to the file
 	return nil
 }
 
 // FindUserByUsername retrieves a user by their username
 func (repo *InMemoryUserRepository) FindUserByUsername(username string) (User, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
 	user, exists := repo.Users[username]
 	if !exists {
 		return User{}, fmt.Errorf("User not found")
@@ -98,23 +291,50 @@ func (repo *InMemoryUserRepository) FindUserByUsername(username string) (User, e
 
 // UpdateUser updates an existing user's profile and saves the changes to the file
 func (repo *InMemoryUserRepository) UpdateUser(user User) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
 	repo.Users[user.Username] = user
+	repo.indexUser(user)
 	repo.saveToFile() // Persist changes to the file
 	return nil
 }
 
-// DeleteUser removes a user from the repository and saves the changes to the file
-func (repo *InMemoryUserRepository) DeleteUser(username string) error {
+// DeleteUser removes a user from the repository and saves the changes to the file.
+// Their blogs, comments, messages and chat messages are removed or, with anonymize set, kept under DeletedUserPlaceholder.
+func (repo *InMemoryUserRepository) DeleteUser(username string, anonymize bool) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
 	if _, exists := repo.Users[username]; !exists {
 		return fmt.Errorf("User not found")
 	}
-	delete(repo.Users, username)
+	repo.deleteUserContent(username, anonymize)
+	repo.deleteUser(username)
 	repo.saveToFile() // Persist changes to the file
 	return nil
 }
 
+// deleteUser removes a user along with their reactions, follows, blocks, chat memberships, admin applications
+// and demotion requests; the caller must hold the lock and is responsible for saving
+func (repo *InMemoryUserRepository) deleteUser(username string) {
+	delete(repo.Users, username)
+	repo.searchIndex.remove("user:" + username)
+	repo.unindexUsername(username)
+	repo.deleteReactionsByUser(username)
+	repo.deleteFollowsByUser(username)
+	repo.deleteBlocksByUser(username)
+	repo.deleteChatMembershipsByUser(username)
+	repo.revokeInvitesByUser(username)
+	repo.deleteApplicationsByUser(username)
+	repo.deleteDemotionsByUser(username)
+}
+
 // GetAllUsers returns all users
 func (repo *InMemoryUserRepository) GetAllUsers() []User {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
 	users := []User{}
 	for _, user := range repo.Users {
 		users = append(users, user)
@@ -122,64 +342,99 @@ func (repo *InMemoryUserRepository) GetAllUsers() []User {
 	return users
 }
 
-// GetPendingAdmins returns all users with pending admin status
-func (repo *InMemoryUserRepository) GetPendingAdmins() []User {
-	pending := []User{}
+// CountAdmins returns the number of users holding the admin role
+func (repo *InMemoryUserRepository) CountAdmins() int {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return repo.countAdmins()
+}
+
+// countAdmins returns the number of admins; the caller must hold the lock
+func (repo *InMemoryUserRepository) countAdmins() int {
+	count := 0
 	for _, user := range repo.Users {
-		if user.Status == "pending" && user.Role == "admin" {
-			pending = append(pending, user)
+		if user.Role == "admin" {
+			count++
 		}
 	}
-	return pending
+	return count
 }
 
-// ApproveAdmin approves a user's admin request and saves the changes to the file
-func (repo *InMemoryUserRepository) ApproveAdmin(username string) error {
-	user, exists := repo.Users[username]
-	if !exists {
-		return fmt.Errorf("User not found")
+// --- Blog Methods ---
+
+// CreateBlog adds a new blog to the repository and saves the changes to the file.
+// The status decides whether the blog is published right away, kept as a draft or scheduled for publishAt.
+func (repo *InMemoryUserRepository) CreateBlog(username, title, text string, tags []string, status, visibility string, publishAt time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	blogID := generateID() // A function to generate a unique ID for the blog
+	now := time.Now()
+	blog := Blog{
+		ID:         blogID,
+		Author:     username, // Link the blog to the user who wrote it
+		Title:      title,
+		Text:       text,
+		Tags:       tags,
+		Status:     status,
+		Visibility: visibility,
+		CreatedAt:  now,
+		UpdatedAt:  now,
 	}
-	if user.Status != "pending" {
-		return fmt.Errorf("User is not pending approval")
+	switch status {
+	case "published":
+		blog.PublishedAt = now
+	case "scheduled":
+		blog.PublishAt = publishAt
+	case "draft":
+	default:
+		return fmt.Errorf("Invalid blog status: %s", status)
 	}
-	user.Status = "approved"
-	repo.Users[username] = user
+	repo.Blogs[blogID] = blog
+	repo.indexAuthor(blog)
+	repo.indexTags(blog)
+	repo.indexBlog(blog)
 	repo.saveToFile() // Persist changes to the file
 	return nil
 }
 
-// RejectAdmin rejects a user's admin request and removes the user from the repository, saving the changes to the file
-func (repo *InMemoryUserRepository) RejectAdmin(username string) error {
-	user, exists := repo.Users[username]
+// DeleteBlog allows a user to delete their own blog and saves the changes to the file
+func (repo *InMemoryUserRepository) DeleteBlog(username, blogID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	blog, exists := repo.Blogs[blogID]
 	if !exists {
-		return fmt.Errorf("User not found")
+		return fmt.Errorf("Blog not found")
 	}
-	if user.Status != "pending" {
-		return fmt.Errorf("User is not pending approval")
+	if blog.Author != username {
+		return fmt.Errorf("You are not the author of this blog")
 	}
-	delete(repo.Users, username)
+	repo.deleteBlog(blog)
 	repo.saveToFile() // Persist changes to the file
 	return nil
 }
 
-// --- Blog Methods ---
+// deleteBlog removes a blog along with its index entries, comments and reactions;
+// the caller must hold the lock and is responsible for saving
+func (repo *InMemoryUserRepository) deleteBlog(blog Blog) {
+	delete(repo.Blogs, blog.ID)
+	delete(repo.authorIndex[blog.Author], blog.ID)
+	repo.unindexTags(blog)
+	repo.searchIndex.remove("blog:" + blog.ID)
+	repo.deleteCommentsByBlog(blog.ID)
+	repo.deleteReactionsByBlog(blog.ID)
+}
 
-// CreateBlog adds a new blog to the repository and saves the changes to the file
-func (repo *InMemoryUserRepository) CreateBlog(username, title, text string) error {
-	blogID := generateBlogID() // A function to generate a unique ID for the blog
-	blog := Blog{
-		ID:     blogID,
-		Author: username, // Link the blog to the user who wrote it
-		Title:  title,
-		Text:   text,
-	}
-	repo.Blogs[blogID] = blog
-	repo.saveToFile() // Persist changes to the file
-	return nil
+// UpdateBlog allows a user to edit their own blog, keeping the previous version as a revision, and saves the changes to the file
+func (repo *InMemoryUserRepository) UpdateBlog(username, blogID, title, text string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	return repo.updateBlog(username, blogID, title, text)
 }
 
-// DeleteBlog allows a user to delete their own blog and saves the changes to the file
-func (repo *InMemoryUserRepository) DeleteBlog(username, blogID string) error {
+// updateBlog edits a blog, keeping the previous version as a revision; the caller must hold the lock
+func (repo *InMemoryUserRepository) updateBlog(username, blogID, title, text string) error {
 	blog, exists := repo.Blogs[blogID]
 	if !exists {
 		return fmt.Errorf("Blog not found")
@@ -187,31 +442,136 @@ func (repo *InMemoryUserRepository) DeleteBlog(username, blogID string) error {
 	if blog.Author != username {
 		return fmt.Errorf("You are not the author of this blog")
 	}
-	delete(repo.Blogs, blogID)
+	if blog.Title == title && blog.Text == text {
+		return fmt.Errorf("Blog is unchanged")
+	}
+	blog.Revisions = append(blog.Revisions, BlogRevision{Title: blog.Title, Text: blog.Text, ReplacedAt: time.Now()})
+	blog.Title = title
+	blog.Text = text
+	blog.UpdatedAt = time.Now()
+	repo.Blogs[blogID] = blog
+	repo.indexBlog(blog)
 	repo.saveToFile() // Persist changes to the file
 	return nil
 }
 
-// GetBlogsByUser returns all blogs written by a specific user
+// RestoreBlogRevision allows a user to bring back an earlier version of their own blog and saves the changes to the file.
+// Revisions are numbered from 1, oldest first; the version being replaced is kept as a new revision.
+func (repo *InMemoryUserRepository) RestoreBlogRevision(username, blogID string, revision int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	blog, exists := repo.Blogs[blogID]
+	if !exists {
+		return fmt.Errorf("Blog not found")
+	}
+	if revision < 1 || revision > len(blog.Revisions) {
+		return fmt.Errorf("Revision not found")
+	}
+	old := blog.Revisions[revision-1]
+	return repo.updateBlog(username, blogID, old.Title, old.Text)
+}
+
+// FindBlogByID retrieves a blog by its ID
+func (repo *InMemoryUserRepository) FindBlogByID(blogID string) (Blog, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	blog, exists := repo.Blogs[blogID]
+	if !exists {
+		return Blog{}, fmt.Errorf("Blog not found")
+	}
+	return blog, nil
+}
+
+// GetBlogsByUser returns all blogs written by a specific user, newest first
 func (repo *InMemoryUserRepository) GetBlogsByUser(username string) []Blog {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	return repo.getBlogsByAuthor(username)
+}
+
+// getBlogsByAuthor returns a user's blogs from the author index, newest first; the caller must hold the lock
+func (repo *InMemoryUserRepository) getBlogsByAuthor(username string) []Blog {
 	userBlogs := []Blog{}
-	for _, blog := range repo.Blogs {
-		if blog.Author == username {
-			userBlogs = append(userBlogs, blog)
-		}
+	for blogID := range repo.authorIndex[username] {
+		userBlogs = append(userBlogs, repo.Blogs[blogID])
 	}
+	sortBlogs(userBlogs)
 	return userBlogs
 }
 
+// indexAuthor adds a blog to the author index
+func (repo *InMemoryUserRepository) indexAuthor(blog Blog) {
+	if repo.authorIndex[blog.Author] == nil {
+		repo.authorIndex[blog.Author] = make(map[string]struct{})
+	}
+	repo.authorIndex[blog.Author][blog.ID] = struct{}{}
+}
+
+// GetAllBlogs returns every blog in the repository, newest first
+func (repo *InMemoryUserRepository) GetAllBlogs() []Blog {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	blogs := []Blog{}
+	for _, blog := range repo.Blogs {
+		blogs = append(blogs, blog)
+	}
+	sortBlogs(blogs)
+	return blogs
+}
+
 // --- Helper Functions ---
 
-// generateBlogID generates a unique ID for each blog
-func generateBlogID() string {
-	return fmt.Sprintf("%d", time.Now().UnixNano())
+// lastID holds the most recently generated ID so that records created within the same clock tick stay unique
+var lastID int64
+
+// generateID generates a unique ID for blogs and other stored records
+func generateID() string {
+	id := time.Now().UnixNano()
+	if id <= lastID {
+		id = lastID + 1
+	}
+	lastID = id
+	return fmt.Sprintf("%d", id)
+}
+
+// sortBlogs orders blogs newest first, see blogNewer
+func sortBlogs(blogs []Blog) {
+	sort.Slice(blogs, func(i, j int) bool {
+		return blogNewer(blogs[i], blogs[j])
+	})
+}
+
+// blogNewer reports whether blog a sorts before blog b: by publication time, or creation time for
+// unpublished blogs, newest first, falling back to the ID for blogs with the same time
+func blogNewer(a, b Blog) bool {
+	sortTime := func(blog Blog) time.Time {
+		if blog.PublishedAt.IsZero() {
+			return blog.CreatedAt
+		}
+		return blog.PublishedAt
+	}
+	if ta, tb := sortTime(a), sortTime(b); !ta.Equal(tb) {
+		return ta.After(tb)
+	}
+	return a.ID > b.ID
+}
+
+// sortApplications orders admin applications by creation time, oldest first
+func sortApplications(applications []AdminApplication) {
+	sort.Slice(applications, func(i, j int) bool {
+		return applications[i].CreatedAt.Before(applications[j].CreatedAt)
+	})
 }
 
 // GetUserBlogs retrieves all blogs created by the specified user.
 func (repo *InMemoryUserRepository) GetUserBlogs(username string) []Blog {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
 	userBlogs := []Blog{}
 	for _, blog := range repo.Blogs {
 		if blog.Author == username {
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// UsernameCollision lists identities whose usernames differ only in case or Unicode form
+type UsernameCollision struct {
+	Canonical string   // The shared canonical form
+	Accounts  []string // Usernames of registered users
+	Authors   []string // Names that only appear as authors of blogs or comments, without an account
+}
+
+// CanonicalUsername returns the form used to compare usernames: Unicode NFC, lowercased
+func CanonicalUsername(username string) string {
+	return strings.ToLower(norm.NFC.String(username))
+}
+
+// --- Username Methods ---
+
+// ResolveUsername returns the stored username matching a name typed by a user. An exact match wins;
+// otherwise the name is compared case-insensitively, as long as that matches exactly one account.
+func (repo *InMemoryUserRepository) ResolveUsername(username string) (string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	if _, exists := repo.Users[username]; exists {
+		return username, nil
+	}
+	matches := repo.usernameIndex[CanonicalUsername(username)]
+	if len(matches) != 1 {
+		return "", fmt.Errorf("User not found")
+	}
+	return matches[0], nil
+}
+
+// FindUsernameCollisions returns every group of identities whose usernames only differ in case or Unicode form
+func (repo *InMemoryUserRepository) FindUsernameCollisions() []UsernameCollision {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return repo.findUsernameCollisions()
+}
+
+// findUsernameCollisions returns every group of colliding identities; the caller must hold the lock
+func (repo *InMemoryUserRepository) findUsernameCollisions() []UsernameCollision {
+	identities := make(map[string]map[string]bool) // canonical form -> names
+	add := func(name string) {
+		canonical := CanonicalUsername(name)
+		if identities[canonical] == nil {
+			identities[canonical] = make(map[string]bool)
+		}
+		identities[canonical][name] = true
+	}
+	for username := range repo.Users {
+		add(username)
+	}
+	for _, blog := range repo.Blogs {
+		add(blog.Author)
+	}
+	for _, comment := range repo.Comments {
+		add(comment.Author)
+	}
+
+	collisions := []UsernameCollision{}
+	for canonical, names := range identities {
+		if len(names) < 2 {
+			continue
+		}
+		collision := UsernameCollision{Canonical: canonical, Accounts: []string{}, Authors: []string{}}
+		for name := range names {
+			if _, exists := repo.Users[name]; exists {
+				collision.Accounts = append(collision.Accounts, name)
+			} else {
+				collision.Authors = append(collision.Authors, name)
+			}
+		}
+		sort.Strings(collision.Accounts)
+		sort.Strings(collision.Authors)
+		collisions = append(collisions, collision)
+	}
+	sort.Slice(collisions, func(i, j int) bool {
+		return collisions[i].Canonical < collisions[j].Canonical
+	})
+	return collisions
+}
+
+// reportUsernameCollisions prints the colliding identities found in the data and records each in the audit log,
+// so that admins can resolve them; the caller must hold the lock
+func (repo *InMemoryUserRepository) reportUsernameCollisions() {
+	for _, collision := range repo.findUsernameCollisions() {
+		detail := "accounts: " + strings.Join(collision.Accounts, ", ")
+		if len(collision.Authors) > 0 {
+			detail += "; authors without an account: " + strings.Join(collision.Authors, ", ")
+		}
+		fmt.Printf("Username collision for %q: %s\n", collision.Canonical, detail)
+		entry := AuditEntry{
+			ID:     generateID(),
+			Time:   time.Now(),
+			Actor:  "system",
+			Action: "username-collision",
+			Target: collision.Canonical,
+			Detail: detail,
+		}
+		repo.AuditLog[entry.ID] = entry
+	}
+}
+
+// indexUsername adds a user to the canonical username index
+func (repo *InMemoryUserRepository) indexUsername(username string) {
+	canonical := CanonicalUsername(username)
+	repo.usernameIndex[canonical] = append(repo.usernameIndex[canonical], username)
+}
+
+// unindexUsername removes a user from the canonical username index
+func (repo *InMemoryUserRepository) unindexUsername(username string) {
+	canonical := CanonicalUsername(username)
+	repo.usernameIndex[canonical] = removeMember(repo.usernameIndex[canonical], username)
+	if len(repo.usernameIndex[canonical]) == 0 {
+		delete(repo.usernameIndex, canonical)
+	}
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// --- Account Lifecycle ---
+
+// ExportUserData returns everything stored about the user as a single indented JSON document
+func (s *UserService) ExportUserData(username string) ([]byte, error) {
+	data, err := s.repo.ExportUserData(username)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(struct {
+		ExportedAt time.Time
+		Data       interface{}
+	}{time.Now(), data}, "", "  ")
+}
+
+// RequestAccountDeletion schedules the user's account for deletion once the grace period has passed.
+// The password must be confirmed, and the last admin cannot delete their account.
+func (s *UserService) RequestAccountDeletion(username, password string) (time.Time, error) {
+	if _, err := s.LoginUser(username, password); err != nil {
+		return time.Time{}, errors.New("password does not match")
+	}
+	deleteAfter := time.Now().Add(s.config.DeletionGracePeriod)
+	if err := s.repo.ScheduleAccountDeletion(username, deleteAfter); err != nil {
+		return time.Time{}, err
+	}
+	return deleteAfter, nil
+}
+
+// CancelAccountDeletion keeps an account whose deletion was requested but has not happened yet
+func (s *UserService) CancelAccountDeletion(username string) error {
+	return s.repo.CancelAccountDeletion(username)
+}
+
+// GetScheduledDeletion returns when the user's account will be deleted, or the zero time if it will not
+func (s *UserService) GetScheduledDeletion(username string) time.Time {
+	user, err := s.repo.FindUserByUsername(username)
+	if err != nil {
+		return time.Time{}
+	}
+	return user.DeleteAfter
+}
+
+// PurgeDueAccounts deletes every account whose grace period has ended, applying the configured content policy;
+// it is run periodically by the server
+func (s *UserService) PurgeDueAccounts(now time.Time) []string {
+	purged := []string{}
+	for _, username := range s.repo.GetAccountsDueForDeletion(now) {
+		if err := s.repo.PurgeUser(username, s.config.DeletedContent == "anonymize", now); err != nil {
+			continue
+		}
+		s.repo.AddAuditEntry("system", "account-deleted", username, fmt.Sprintf("content policy: %s", s.config.DeletedContent))
+		purged = append(purged, username)
+	}
+	return purged
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"go-socket-server/models"
+	"strings"
+	"unicode"
+)
+
+const maxRoomNameLength = 32
+
+// normalizeRoomName lowercases a room name and strips a leading "#".
+// Names may only contain letters, digits, "-" and "_".
+func normalizeRoomName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "#"))
+	if name == "" {
+		return "", errors.New("room name cannot be empty")
+	}
+	if len(name) > maxRoomNameLength {
+		return "", fmt.Errorf("room name is too long (maximum is %d characters)", maxRoomNameLength)
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return "", errors.New("room name may only contain letters, digits, \"-\" and \"_\"")
+		}
+	}
+	return name, nil
+}
+
+// --- Chat Rooms ---
+
+// CreateChatRoom creates a chat room with the creator as its first member
+func (s *UserService) CreateChatRoom(owner, name string) (models.ChatRoom, error) {
+	name, err := normalizeRoomName(name)
+	if err != nil {
+		return models.ChatRoom{}, err
+	}
+	return s.repo.CreateChatRoom(name, owner)
+}
+
+// JoinChatRoom makes the user a member of a room and tells the other members.
+// The returned room carries the scrollback to replay to the user.
+func (s *UserService) JoinChatRoom(username, name string) (models.ChatRoom, error) {
+	name, err := normalizeRoomName(name)
+	if err != nil {
+		return models.ChatRoom{}, err
+	}
+	before, err := s.repo.FindChatRoom(name)
+	if err != nil {
+		return models.ChatRoom{}, err
+	}
+	room, err := s.repo.JoinChatRoom(name, username)
+	if err != nil {
+		return models.ChatRoom{}, err
+	}
+	if !before.HasMember(username) {
+		s.notifyRoom(room, username, fmt.Sprintf("[#%s] %s joined the room", room.Name, username))
+	}
+	return room, nil
+}
+
+// LeaveChatRoom removes the user from a room and tells the remaining members
+func (s *UserService) LeaveChatRoom(username, name string) error {
+	name, err := normalizeRoomName(name)
+	if err != nil {
+		return err
+	}
+	room, err := s.repo.LeaveChatRoom(name, username)
+	if err != nil {
+		return err
+	}
+	s.notifyRoom(room, username, fmt.Sprintf("[#%s] %s left the room", room.Name, username))
+	return nil
+}
+
+// SendChatMessage posts a message to a room and fans it out live to every other member who is online
+func (s *UserService) SendChatMessage(from, name, text string) (models.ChatMessage, error) {
+	name, err := normalizeRoomName(name)
+	if err != nil {
+		return models.ChatMessage{}, err
+	}
+	if text == "" {
+		return models.ChatMessage{}, errors.New("message cannot be empty")
+	}
+	if len(text) > s.config.MaxMessageBytes {
+		return models.ChatMessage{}, fmt.Errorf("message is too long (%d bytes, maximum is %d)", len(text), s.config.MaxMessageBytes)
+	}
+	room, message, err := s.repo.AddChatMessage(name, from, text, s.config.ChatScrollback)
+	if err != nil {
+		return models.ChatMessage{}, err
+	}
+	s.notifyRoom(room, from, fmt.Sprintf("[#%s] %s: %s", room.Name, from, text))
+	return message, nil
+}
+
+// GetChatRooms fetches every chat room sorted by name
+func (s *UserService) GetChatRooms() []models.ChatRoom {
+	return s.repo.GetChatRooms()
+}
+
+// notifyRoom pushes text to every member of a room except the user who caused it
+func (s *UserService) notifyRoom(room models.ChatRoom, except, text string) {
+	if s.notifier == nil {
+		return
+	}
+	for _, member := range room.Members {
+		if member != except {
+			s.notifier.Notify(member, text)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"golang.org/x/crypto/bcrypt"
+	"time"
+)
+
+// Config holds the policy settings used by UserService
+type Config struct {
+	AdminQuorum     int           // Number of distinct admins that must approve a promotion or demotion
+	PageSize        int           // Number of items shown per page in listings such as the blog feed
+	MaxBlogBytes    int           // Maximum size of a blog body in bytes
+	MaxCommentBytes int           // Maximum size of a comment in bytes
+	MaxMessageBytes int           // Maximum size of a direct or chat room message in bytes
+	ChatScrollback  int           // Number of recent chat room messages kept and replayed when joining a room
+	IdleAfter       time.Duration // How long a connected user may stay silent before they are shown as idle
+
+	UsernameMinLength int      // Minimum length of a new username in characters
+	UsernameMaxLength int      // Maximum length of a new username in characters
+	UsernameCharset   string   // Characters allowed in a lowercased username, as the inside of a regexp character class
+	ReservedUsernames []string // Names nobody may register, compared case-insensitively
+
+	DeletionGracePeriod time.Duration // How long a deleted account can still be restored before it is removed for good
+	DeletedContent      string        // What happens to a deleted account's content: "remove" or "anonymize"
+
+	RegistrationMode string        // Who may create an account: "open", "approval-required" or "invite-only"
+	InviteQuota      int           // How many people a regular user may invite; 0 leaves invite codes to admins
+	InviteValidity   time.Duration // How long a new invite code can be used
+
+	PasswordHash    string // Algorithm for new password hashes: "bcrypt" or "argon2id"
+	BcryptCost      int    // bcrypt work factor
+	Argon2Time      int    // argon2id number of passes
+	Argon2MemoryKiB int    // argon2id memory use in KiB
+	Argon2Threads   int    // argon2id parallelism
+}
+
+// DefaultConfig returns the settings used when nothing is configured on the command line
+func DefaultConfig() Config {
+	return Config{
+		AdminQuorum:     1,
+		PageSize:        10,
+		MaxBlogBytes:    64 * 1024,
+		MaxCommentBytes: 4 * 1024,
+		MaxMessageBytes: 2 * 1024,
+		ChatScrollback:  50,
+		IdleAfter:       5 * time.Minute,
+
+		UsernameMinLength: 3,
+		UsernameMaxLength: 20,
+		UsernameCharset:   "a-z0-9_.-",
+		ReservedUsernames: []string{"admin", "administrator", "root", "system", "moderator", "support", "help", "server"},
+
+		DeletionGracePeriod: 7 * 24 * time.Hour,
+		DeletedContent:      "remove",
+
+		RegistrationMode: "open",
+		InviteQuota:      0,
+		InviteValidity:   7 * 24 * time.Hour,
+
+		PasswordHash:    "bcrypt",
+		BcryptCost:      bcrypt.DefaultCost,
+		Argon2Time:      1,
+		Argon2MemoryKiB: 64 * 1024,
+		Argon2Threads:   4,
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"go-socket-server/models"
+	"sort"
+	"strings"
+)
+
+// interestFields are the profile fields compared when suggesting users with shared interests
+var interestFields = []string{"football-team", "city", "fav-animal", "fav-movie"}
+
+// maxStatsValues is the number of most common values shown per field in the profile statistics
+const maxStatsValues = 5
+
+// Suggestion is a user recommended to the viewer along with the profile fields they have in common
+type Suggestion struct {
+	Username string
+	Shared   []models.ProfileField
+}
+
+// ValueCount pairs a profile value with the number of users who entered it
+type ValueCount struct {
+	Value string
+	Count int
+}
+
+// FieldStats holds the most common values of one profile field
+type FieldStats struct {
+	Field  models.ProfileField
+	Filled int // Number of users who filled in the field
+	Top    []ValueCount
+}
+
+// --- Discovery ---
+
+// isListed reports whether a user may be suggested to others or found by them;
+// accounts still waiting for approval or scheduled for deletion are not
+func isListed(user models.User) bool {
+	return user.Status == "approved" && user.DeleteAfter.IsZero()
+}
+
+// Discover fetches one page of users who share the viewer's football team, city, favorite animal or favorite movie,
+// those with the most in common first. Only fields the other user lets the viewer see are compared.
+// Users the viewer already follows, users either side has blocked and users hiding their presence are left out,
+// as are accounts still waiting for approval or scheduled for deletion.
+func (s *UserService) Discover(viewer string, page int) ([]Suggestion, int, error) {
+	me, err := s.repo.FindUserByUsername(viewer)
+	if err != nil {
+		return nil, 0, err
+	}
+	fields := s.interestSchema()
+
+	suggestions := []Suggestion{}
+	for _, user := range s.repo.GetAllUsers() {
+		if user.Username == viewer || user.HidePresence || !isListed(user) ||
+			s.repo.IsBlocked(viewer, user.Username) || s.repo.IsBlocked(user.Username, viewer) ||
+			s.repo.IsFollowing(viewer, user.Username) {
+			continue
+		}
+		shared := []models.ProfileField{}
+		for _, field := range fields {
+			mine, theirs := me.ProfileField(field.Key), user.ProfileField(field.Key)
+			if mine == "" || !strings.EqualFold(mine, theirs) {
+				continue
+			}
+			if s.canSeeProfileField(viewer, user.Username, user.FieldVisibility(field)) {
+				shared = append(shared, field)
+			}
+		}
+		if len(shared) > 0 {
+			suggestions = append(suggestions, Suggestion{Username: user.Username, Shared: shared})
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if len(suggestions[i].Shared) != len(suggestions[j].Shared) {
+			return len(suggestions[i].Shared) > len(suggestions[j].Shared)
+		}
+		return suggestions[i].Username < suggestions[j].Username
+	})
+	pageSuggestions, pages := paginate(suggestions, page, s.config.PageSize)
+	return pageSuggestions, pages, nil
+}
+
+// GetProfileStats fetches the most common football teams, cities and favorites across all users; admins only.
+// Values are grouped without regard to case.
+func (s *UserService) GetProfileStats(admin string) (int, []FieldStats, error) {
+	if err := s.requireAdmin(admin); err != nil {
+		return 0, nil, err
+	}
+	users := s.repo.GetAllUsers()
+	stats := []FieldStats{}
+	for _, field := range s.interestSchema() {
+		counts := make(map[string]*ValueCount)
+		filled := 0
+		for _, user := range users {
+			value := user.ProfileField(field.Key)
+			if value == "" {
+				continue
+			}
+			filled++
+			key := strings.ToLower(value)
+			if counts[key] == nil {
+				counts[key] = &ValueCount{Value: value}
+			}
+			counts[key].Count++
+		}
+		top := []ValueCount{}
+		for _, count := range counts {
+			top = append(top, *count)
+		}
+		sort.Slice(top, func(i, j int) bool {
+			if top[i].Count != top[j].Count {
+				return top[i].Count > top[j].Count
+			}
+			return strings.ToLower(top[i].Value) < strings.ToLower(top[j].Value)
+		})
+		if len(top) > maxStatsValues {
+			top = top[:maxStatsValues]
+		}
+		stats = append(stats, FieldStats{Field: field, Filled: filled, Top: top})
+	}
+	return len(users), stats, nil
+}
+
+// interestSchema returns the schema entries of the interestFields, in that order
+func (s *UserService) interestSchema() []models.ProfileField {
+	fields := []models.ProfileField{}
+	schema := s.repo.GetProfileSchema()
+	for _, key := range interestFields {
+		for _, field := range schema {
+			if field.Key == key {
+				fields = append(fields, field)
+			}
+		}
+	}
+	return fields
+}
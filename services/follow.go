@@ -0,0 +1,57 @@
+package services
+
+import "go-socket-server/models"
+
+// --- Following and Timeline ---
+
+// Follow makes the user follow another user
+func (s *UserService) Follow(username, followee string) error {
+	followee = s.resolveUsername(followee)
+	return s.repo.FollowUser(username, followee)
+}
+
+// Unfollow makes the user stop following another user
+func (s *UserService) Unfollow(username, followee string) error {
+	followee = s.resolveUsername(followee)
+	return s.repo.UnfollowUser(username, followee)
+}
+
+// GetFollowers fetches the usernames following a user
+func (s *UserService) GetFollowers(username string) ([]string, error) {
+	username = s.resolveUsername(username)
+	if _, err := s.repo.FindUserByUsername(username); err != nil {
+		return nil, err
+	}
+	return s.repo.GetFollowers(username), nil
+}
+
+// GetFollowing fetches the usernames a user follows
+func (s *UserService) GetFollowing(username string) ([]string, error) {
+	username = s.resolveUsername(username)
+	if _, err := s.repo.FindUserByUsername(username); err != nil {
+		return nil, err
+	}
+	return s.repo.GetFollowing(username), nil
+}
+
+// GetTimeline fetches one page of published blogs from the users the viewer follows, newest first,
+// and reports whether there are more pages after it
+func (s *UserService) GetTimeline(viewer string, page int) ([]models.Blog, bool) {
+	if page < 1 {
+		page = 1
+	}
+	include := func(blog models.Blog) bool {
+		return blog.Status == "published" && s.canView(viewer, blog)
+	}
+	// Take one blog past the requested page to find out whether another page follows
+	blogs := s.repo.GetTimeline(viewer, include, page*s.config.PageSize+1)
+	start := (page - 1) * s.config.PageSize
+	if start >= len(blogs) {
+		return []models.Blog{}, false
+	}
+	end := start + s.config.PageSize
+	if end >= len(blogs) {
+		return blogs[start:], false
+	}
+	return blogs[start:end], true
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"go-socket-server/models"
+	"sort"
+	"time"
+)
+
+// InviteTreeNode is one account in an invite tree, listed after the account that invited it
+type InviteTreeNode struct {
+	Username  string
+	InvitedBy string // Empty for the accounts the tree starts from when they were not invited
+	Code      string // Invite code the account registered with, if any
+	Depth     int    // 0 for the accounts the tree starts from
+}
+
+// --- Invites ---
+
+// CreateInvite creates an invite code that can register up to maxUses accounts within validFor.
+// A zero validFor uses the configured validity. Admins may create any number of codes;
+// other users may only hand out as many invitations as their quota allows and no longer than the configured validity.
+func (s *UserService) CreateInvite(username string, maxUses int, validFor time.Duration) (models.InviteCode, error) {
+	if maxUses < 1 {
+		return models.InviteCode{}, errors.New("an invite code must allow at least one use")
+	}
+	if validFor <= 0 {
+		validFor = s.config.InviteValidity
+	}
+	quota := -1 // Admins are not limited
+	if !s.IsAdmin(username) {
+		quota = s.config.InviteQuota
+		if validFor > s.config.InviteValidity {
+			validFor = s.config.InviteValidity
+		}
+	}
+	invite, err := s.repo.CreateInvite(username, maxUses, time.Now().Add(validFor), quota)
+	if err != nil {
+		return models.InviteCode{}, err
+	}
+	s.repo.AddAuditEntry(username, "create-invite", invite.Code,
+		fmt.Sprintf("%d uses, expires %s", invite.MaxUses, invite.ExpiresAt.Format("2006-01-02 15:04")))
+	return invite, nil
+}
+
+// RemainingInvites returns how many more people a regular user may invite.
+// Codes that can still be used count with all their uses; expired and revoked codes only with the uses they got.
+func (s *UserService) RemainingInvites(username string) int {
+	return s.repo.RemainingInvites(username, s.config.InviteQuota)
+}
+
+// GetMyInvites fetches the invite codes the user created, newest first
+func (s *UserService) GetMyInvites(username string) []models.InviteCode {
+	return s.repo.GetInvites(username)
+}
+
+// GetAllInvites fetches one page of every invite code, newest first; admins only
+func (s *UserService) GetAllInvites(admin string, page int) ([]models.InviteCode, int, error) {
+	if err := s.requireAdmin(admin); err != nil {
+		return nil, 0, err
+	}
+	invites, pages := paginate(s.repo.GetInvites(""), page, s.config.PageSize)
+	return invites, pages, nil
+}
+
+// RevokeInvite stops an invite code from being used again; only its creator or an admin may revoke it
+func (s *UserService) RevokeInvite(username, code string) error {
+	invite, err := s.repo.FindInvite(code)
+	if err != nil {
+		return err
+	}
+	if invite.CreatedBy != username && !s.IsAdmin(username) {
+		return errors.New("you can only revoke your own invite codes")
+	}
+	if _, err := s.repo.RevokeInvite(code, username); err != nil {
+		return err
+	}
+	s.repo.AddAuditEntry(username, "revoke-invite", code, fmt.Sprintf("created by %s, used %d/%d", invite.CreatedBy, len(invite.UsedBy), invite.MaxUses))
+	return nil
+}
+
+// GetInviteTree traces who invited whom; admins only. Starting from a user it lists everyone they invited,
+// directly or through others; without one it starts from every account that invited someone without being invited.
+func (s *UserService) GetInviteTree(admin, username string) ([]InviteTreeNode, error) {
+	if err := s.requireAdmin(admin); err != nil {
+		return nil, err
+	}
+
+	invitedBy := make(map[string]string)  // username -> inviter
+	invitees := make(map[string][]string) // inviter -> usernames
+	codes := make(map[string]string)      // username -> invite code
+	for _, user := range s.repo.GetAllUsers() {
+		if user.InvitedWith == "" {
+			continue
+		}
+		codes[user.Username] = user.InvitedWith
+		if invite, err := s.repo.FindInvite(user.InvitedWith); err == nil {
+			invitedBy[user.Username] = invite.CreatedBy
+			invitees[invite.CreatedBy] = append(invitees[invite.CreatedBy], user.Username)
+		}
+	}
+	for inviter := range invitees {
+		sort.Strings(invitees[inviter])
+	}
+
+	roots := []string{}
+	if username != "" {
+		resolved, err := s.repo.ResolveUsername(username)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, resolved)
+	} else {
+		for inviter := range invitees {
+			if invitedBy[inviter] == "" {
+				roots = append(roots, inviter)
+			}
+		}
+		sort.Strings(roots)
+	}
+
+	nodes := []InviteTreeNode{}
+	visited := make(map[string]bool)
+	var walk func(username string, depth int)
+	walk = func(username string, depth int) {
+		if visited[username] {
+			return
+		}
+		visited[username] = true
+		nodes = append(nodes, InviteTreeNode{Username: username, InvitedBy: invitedBy[username], Code: codes[username], Depth: depth})
+		for _, invitee := range invitees[username] {
+			walk(invitee, depth+1)
+		}
+	}
+	for _, root := range roots {
+		walk(root, 0)
+	}
+	return nodes, nil
+}
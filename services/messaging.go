@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"go-socket-server/models"
+)
+
+// Notifier pushes a line of text to the live connections of a user.
+// It reports whether at least one connection received it.
+type Notifier interface {
+	Notify(username, text string) bool
+}
+
+// SetNotifier sets how the service reaches users who are online; without one every message is queued
+func (s *UserService) SetNotifier(notifier Notifier) {
+	s.notifier = notifier
+}
+
+// --- Direct Messages ---
+
+// SendMessage stores a direct message and delivers it right away if the recipient is online;
+// otherwise it stays queued until the recipient logs in
+func (s *UserService) SendMessage(from, to, text string) error {
+	to = s.resolveUsername(to)
+	if text == "" {
+		return errors.New("message cannot be empty")
+	}
+	if len(text) > s.config.MaxMessageBytes {
+		return fmt.Errorf("message is too long (%d bytes, maximum is %d)", len(text), s.config.MaxMessageBytes)
+	}
+	message, err := s.repo.CreateMessage(from, to, text)
+	if err != nil {
+		return err
+	}
+	if s.notifier != nil && s.notifier.Notify(to, fmt.Sprintf("[Message from %s] %s", from, text)) {
+		s.repo.MarkMessagesDelivered([]string{message.ID})
+	}
+	return nil
+}
+
+// DeliverQueuedMessages returns the messages that arrived while the user was offline and marks them delivered
+func (s *UserService) DeliverQueuedMessages(username string) []models.Message {
+	messages := s.repo.GetUndeliveredMessages(username)
+	if len(messages) > 0 {
+		ids := []string{}
+		for _, message := range messages {
+			ids = append(ids, message.ID)
+		}
+		s.repo.MarkMessagesDelivered(ids)
+	}
+	return messages
+}
+
+// GetInbox fetches a summary of the user's conversations, most recent first
+func (s *UserService) GetInbox(username string) []models.ConversationSummary {
+	return s.repo.GetInbox(username)
+}
+
+// GetConversation fetches one page of the messages exchanged with another user and marks the ones received as read.
+// Page 1 holds the most recent messages; messages within a page are oldest first.
+func (s *UserService) GetConversation(username, other string, page int) ([]models.Message, int, error) {
+	other = s.resolveUsername(other)
+	if _, err := s.repo.FindUserByUsername(other); err != nil {
+		return nil, 0, err
+	}
+	messages := s.repo.GetConversation(username, other)
+	s.repo.MarkConversationRead(username, other)
+
+	// Paginate from the newest message backwards, then restore chronological order within the page
+	newestFirst := make([]models.Message, len(messages))
+	for i, message := range messages {
+		newestFirst[len(messages)-1-i] = message
+	}
+	pageMessages, pages := paginate(newestFirst, page, s.config.PageSize)
+	chronological := make([]models.Message, len(pageMessages))
+	for i, message := range pageMessages {
+		chronological[len(pageMessages)-1-i] = message
+	}
+	return chronological, pages, nil
+}
+
+// BlockUser stops another user from sending the user direct messages
+func (s *UserService) BlockUser(username, blocked string) error {
+	blocked = s.resolveUsername(blocked)
+	return s.repo.BlockUser(username, blocked)
+}
+
+// UnblockUser allows a previously blocked user to send the user direct messages again
+func (s *UserService) UnblockUser(username, blocked string) error {
+	blocked = s.resolveUsername(blocked)
+	return s.repo.UnblockUser(username, blocked)
+}
+
+// GetBlockedUsers fetches the usernames the user has blocked
+func (s *UserService) GetBlockedUsers(username string) []string {
+	return s.repo.GetBlockedUsers(username)
+}
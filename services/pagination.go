@@ -0,0 +1,22 @@
+package services
+
+// paginate returns the items on the given 1-based page and the total number of pages.
+// Pages past the end return an empty slice; there is always at least one page.
+func paginate[T any](items []T, page, size int) ([]T, int) {
+	pages := (len(items) + size - 1) / size
+	if pages == 0 {
+		pages = 1
+	}
+	if page < 1 {
+		page = 1
+	}
+	start := (page - 1) * size
+	if start >= len(items) {
+		return []T{}, pages
+	}
+	end := start + size
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end], pages
+}
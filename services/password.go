@@ -0,0 +1,120 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+// PasswordHashes are the accepted values of Config.PasswordHash
+var PasswordHashes = []string{"bcrypt", "argon2id"}
+
+// argon2KeyLength and argon2SaltLength are the sizes in bytes of the derived key and salt in new argon2id hashes
+const (
+	argon2KeyLength  = 32
+	argon2SaltLength = 16
+)
+
+// argon2MaxMemoryKiB is the most memory an argon2id hash may ask for, so a damaged hash cannot exhaust the server
+const argon2MaxMemoryKiB = 1024 * 1024
+
+// passwordHasher hashes new passwords with the configured algorithm and checks passwords against stored hashes.
+// Stored hashes carry their own algorithm and parameters, so hashes made under an older policy keep working.
+type passwordHasher interface {
+	Hash(password string) (string, error)
+	// NeedsRehash reports whether a stored hash uses another algorithm or weaker parameters than the policy
+	NeedsRehash(hash string) bool
+}
+
+// newPasswordHasher returns the hasher for the configured algorithm
+func newPasswordHasher(config Config) passwordHasher {
+	if config.PasswordHash == "argon2id" {
+		return argon2idHasher{time: uint32(config.Argon2Time), memory: uint32(config.Argon2MemoryKiB), threads: uint8(config.Argon2Threads)}
+	}
+	return bcryptHasher{cost: config.BcryptCost}
+}
+
+// verifyPassword checks a password against a stored hash of any supported algorithm
+func verifyPassword(hash, password string) error {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		params, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return err
+		}
+		candidate := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(candidate, key) != 1 {
+			return errors.New("password does not match")
+		}
+		return nil
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+}
+
+// bcryptHasher hashes passwords with bcrypt at the given cost
+type bcryptHasher struct {
+	cost int
+}
+
+func (h bcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	return string(hash), err
+}
+
+func (h bcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost < h.cost
+}
+
+// argon2idHasher hashes passwords with argon2id, stored in the usual $argon2id$v=19$m=...,t=...,p=...$salt$key form
+type argon2idHasher struct {
+	time    uint32
+	memory  uint32 // In KiB
+	threads uint8
+}
+
+func (h argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.time, h.memory, h.threads, argon2KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.memory, h.time, h.threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h argon2idHasher) NeedsRehash(hash string) bool {
+	params, _, key, err := decodeArgon2id(hash)
+	return err != nil || params.time < h.time || params.memory < h.memory || params.threads < h.threads ||
+		len(key) < argon2KeyLength
+}
+
+// decodeArgon2id splits a stored argon2id hash into its parameters, salt and derived key
+func decodeArgon2id(hash string) (argon2idHasher, []byte, []byte, error) {
+	var params argon2idHasher
+	var version int
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errors.New("malformed argon2id hash")
+	}
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errors.New("unsupported argon2id version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil ||
+		params.time < 1 || params.threads < 1 || params.memory < 8*uint32(params.threads) || params.memory > argon2MaxMemoryKiB {
+		return params, nil, nil, errors.New("malformed argon2id parameters")
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, errors.New("malformed argon2id salt")
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errors.New("malformed argon2id key")
+	}
+	return params, salt, key, nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"testing"
+)
+
+// testArgon2id keeps the argon2id tests fast
+var testArgon2id = argon2idHasher{time: 1, memory: 64, threads: 1}
+
+func TestHashAndVerify(t *testing.T) {
+	hashers := map[string]passwordHasher{
+		"bcrypt":   bcryptHasher{cost: bcrypt.MinCost},
+		"argon2id": testArgon2id,
+	}
+	for name, hasher := range hashers {
+		hash, err := hasher.Hash("correct horse")
+		if err != nil {
+			t.Fatalf("%s: Hash: %v", name, err)
+		}
+		if err := verifyPassword(hash, "correct horse"); err != nil {
+			t.Errorf("%s: verifyPassword with the right password: %v", name, err)
+		}
+		if err := verifyPassword(hash, "wrong horse"); err == nil {
+			t.Errorf("%s: verifyPassword accepted a wrong password", name)
+		}
+		if hasher.NeedsRehash(hash) {
+			t.Errorf("%s: NeedsRehash reported a fresh hash", name)
+		}
+	}
+}
+
+func TestArgon2idHashFormat(t *testing.T) {
+	hash, err := testArgon2id.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("unexpected hash format %q", hash)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	weakBcrypt, _ := bcryptHasher{cost: bcrypt.MinCost}.Hash("secret")
+	weakArgon2id, _ := testArgon2id.Hash("secret")
+	tests := []struct {
+		name   string
+		hasher passwordHasher
+		hash   string
+		want   bool
+	}{
+		{"bcrypt at the policy cost", bcryptHasher{cost: bcrypt.MinCost}, weakBcrypt, false},
+		{"bcrypt below the policy cost", bcryptHasher{cost: bcrypt.MinCost + 1}, weakBcrypt, true},
+		{"argon2id under a bcrypt policy", bcryptHasher{cost: bcrypt.MinCost}, weakArgon2id, true},
+		{"bcrypt under an argon2id policy", testArgon2id, weakBcrypt, true},
+		{"argon2id with the policy parameters", testArgon2id, weakArgon2id, false},
+		{"argon2id with fewer passes", argon2idHasher{time: 2, memory: 64, threads: 1}, weakArgon2id, true},
+		{"argon2id with less memory", argon2idHasher{time: 1, memory: 128, threads: 1}, weakArgon2id, true},
+		{"argon2id with fewer threads", argon2idHasher{time: 1, memory: 64, threads: 2}, weakArgon2id, true},
+		{"malformed hash", testArgon2id, "$argon2id$garbage", true},
+	}
+	for _, test := range tests {
+		if got := test.hasher.NeedsRehash(test.hash); got != test.want {
+			t.Errorf("%s: NeedsRehash = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestVerifyMalformedArgon2id(t *testing.T) {
+	const salt, key = "c2FsdHNhbHRzYWx0c2FsdA", "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"
+	tests := map[string]string{
+		"too few parts":     "$argon2id$v=19$m=64,t=1,p=1$" + salt,
+		"other version":     "$argon2id$v=16$m=64,t=1,p=1$" + salt + "$" + key,
+		"no parameters":     "$argon2id$v=19$$" + salt + "$" + key,
+		"zero passes":       "$argon2id$v=19$m=64,t=0,p=1$" + salt + "$" + key,
+		"zero threads":      "$argon2id$v=19$m=64,t=1,p=0$" + salt + "$" + key,
+		"too many threads":  "$argon2id$v=19$m=4096,t=1,p=300$" + salt + "$" + key,
+		"too little memory": "$argon2id$v=19$m=8,t=1,p=2$" + salt + "$" + key,
+		"too much memory":   "$argon2id$v=19$m=4294967295,t=1,p=1$" + salt + "$" + key,
+		"negative memory":   "$argon2id$v=19$m=-1,t=1,p=1$" + salt + "$" + key,
+		"bad salt":          "$argon2id$v=19$m=64,t=1,p=1$!!!$" + key,
+		"bad key":           "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$!!!",
+		"empty key":         "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$",
+	}
+	for name, hash := range tests {
+		if err := verifyPassword(hash, "secret"); err == nil {
+			t.Errorf("%s: verifyPassword accepted %q", name, hash)
+		}
+	}
+}
+
+func TestDecodeArgon2idParameterErrors(t *testing.T) {
+	for _, parameters := range []string{"m=64,t=0,p=1", "m=64,t=1,p=0", "m=15,t=1,p=2", "m=2097152,t=1,p=1"} {
+		_, _, _, err := decodeArgon2id("$argon2id$v=19$" + parameters + "$c2FsdA$a2V5")
+		if err == nil || err.Error() != "malformed argon2id parameters" {
+			t.Errorf("%s: got error %v, want malformed argon2id parameters", parameters, err)
+		}
+	}
+}
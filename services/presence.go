@@ -0,0 +1,81 @@
+package services
+
+import (
+	"sort"
+	"time"
+)
+
+// SessionTracker reports which users currently have live connections
+type SessionTracker interface {
+	// LastActivity returns when the user last sent input on any of their connections, and false if they have none
+	LastActivity(username string) (time.Time, bool)
+	// OnlineUsers returns the usernames with at least one live connection
+	OnlineUsers() []string
+}
+
+// Presence describes whether a user is around, as seen by another user
+type Presence struct {
+	Username string
+	Status   string    // "online", "idle" or "offline"
+	Since    time.Time // Last activity when online or idle, last connection when offline; zero if unknown
+}
+
+// SetSessionTracker sets where the service learns about live connections; without one every user is offline
+func (s *UserService) SetSessionTracker(tracker SessionTracker) {
+	s.sessions = tracker
+}
+
+// --- Presence ---
+
+// RecordLastSeen stores the current time as the moment the user was last connected.
+// It is called when a user logs in and again when their connection closes.
+func (s *UserService) RecordLastSeen(username string) error {
+	return s.repo.RecordLastSeen(username, time.Now())
+}
+
+// SetPresenceHidden sets whether the user appears offline to everyone else
+func (s *UserService) SetPresenceHidden(username string, hidden bool) error {
+	return s.repo.SetPresenceHidden(username, hidden)
+}
+
+// GetPresence fetches the presence of a user as seen by the viewer.
+// Users who hide their presence appear offline, with no last-seen time, to everyone but themselves.
+func (s *UserService) GetPresence(viewer, username string) (Presence, error) {
+	username = s.resolveUsername(username)
+	user, err := s.repo.FindUserByUsername(username)
+	if err != nil {
+		return Presence{}, err
+	}
+	if user.HidePresence && viewer != username {
+		return Presence{Username: username, Status: "offline"}, nil
+	}
+	presence := Presence{Username: username, Status: "offline", Since: user.LastSeen}
+	if s.sessions != nil {
+		if lastActivity, online := s.sessions.LastActivity(username); online {
+			presence.Status = "online"
+			presence.Since = lastActivity
+			if time.Since(lastActivity) >= s.config.IdleAfter {
+				presence.Status = "idle"
+			}
+		}
+	}
+	return presence, nil
+}
+
+// WhoIsOnline fetches the presence of every connected user the viewer may see, sorted by username
+func (s *UserService) WhoIsOnline(viewer string) []Presence {
+	online := []Presence{}
+	if s.sessions == nil {
+		return online
+	}
+	usernames := s.sessions.OnlineUsers()
+	sort.Strings(usernames)
+	for _, username := range usernames {
+		presence, err := s.GetPresence(viewer, username)
+		if err != nil || presence.Status == "offline" {
+			continue
+		}
+		online = append(online, presence)
+	}
+	return online
+}
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"go-socket-server/models"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	maxProfileTextLength = 64   // Default maximum length of a text profile field in characters
+	maxProfileKeyLength  = 32   // Maximum length of the key of a custom profile field
+	minYear              = 1900 // Earliest accepted year when a year field sets no minimum
+)
+
+// FieldError describes why the value given for one field was rejected
+type FieldError struct {
+	Field   string // Key of the field in the profile schema
+	Message string
+}
+
+// ValidationError lists every field that failed validation in a single update
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error joins the field errors into one line
+func (e *ValidationError) Error() string {
+	messages := []string{}
+	for _, field := range e.Fields {
+		messages = append(messages, field.Field+": "+field.Message)
+	}
+	return "invalid profile: " + strings.Join(messages, "; ")
+}
+
+// validateProfileValue normalizes the value of a profile field and checks it against the field's rules.
+// Text is trimmed and converted to Unicode NFC so that look-alike spellings are stored the same way.
+func validateProfileValue(field models.ProfileField, value string) (string, error) {
+	if !utf8.ValidString(value) {
+		return "", fmt.Errorf("must be valid UTF-8 text")
+	}
+	value = norm.NFC.String(strings.TrimSpace(value))
+	for _, r := range value {
+		if unicode.IsControl(r) {
+			return "", fmt.Errorf("must not contain control characters")
+		}
+	}
+	if value == "" {
+		if field.Required {
+			return "", fmt.Errorf("is required")
+		}
+		return value, nil
+	}
+
+	switch field.Type {
+	case "year":
+		year, err := strconv.Atoi(value)
+		if err != nil {
+			return "", fmt.Errorf("must be a whole number")
+		}
+		min, max := yearRange(field)
+		if year < min || year > max {
+			return "", fmt.Errorf("must be between %d and %d", min, max)
+		}
+	case "number":
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return "", fmt.Errorf("must be a whole number")
+		}
+		if number < field.Min || number > field.Max {
+			return "", fmt.Errorf("must be between %d and %d", field.Min, field.Max)
+		}
+	default:
+		maxLength := field.MaxLength
+		if maxLength == 0 {
+			maxLength = maxProfileTextLength
+		}
+		if length := utf8.RuneCountInString(value); length > maxLength {
+			return "", fmt.Errorf("must be at most %d characters (got %d)", maxLength, length)
+		}
+		if field.Pattern != "" {
+			if matched, err := regexp.MatchString("^(?:"+field.Pattern+")$", value); err != nil || !matched {
+				return "", fmt.Errorf("must match the pattern %s", field.Pattern)
+			}
+		}
+	}
+	return value, nil
+}
+
+// yearRange returns the years a year field accepts, filling in the defaults for an unset minimum or maximum
+func yearRange(field models.ProfileField) (int, int) {
+	min, max := field.Min, field.Max
+	if min == 0 {
+		min = minYear
+	}
+	if max == 0 {
+		max = time.Now().Year()
+	}
+	return min, max
+}
+
+// missingRequired returns the required fields of the schema that have no value
+func missingRequired(schema []models.ProfileField, value func(key string) string) []models.ProfileField {
+	missing := []models.ProfileField{}
+	for _, field := range schema {
+		if field.Required && value(field.Key) == "" {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+// validateProfile normalizes a set of profile changes against the schema,
+// collecting an error for every field that is invalid
+func validateProfile(schema []models.ProfileField, changes map[string]string) (map[string]string, error) {
+	known := make(map[string]bool)
+	for _, field := range schema {
+		known[field.Key] = true
+	}
+
+	validated := make(map[string]string)
+	invalid := &ValidationError{}
+	// Check the known fields in schema order so errors are always reported in the same order
+	for _, field := range schema {
+		value, present := changes[field.Key]
+		if !present {
+			continue
+		}
+		normalized, err := validateProfileValue(field, value)
+		if err != nil {
+			invalid.Fields = append(invalid.Fields, FieldError{Field: field.Key, Message: err.Error()})
+			continue
+		}
+		validated[field.Key] = normalized
+	}
+	for key := range changes {
+		if !known[key] {
+			invalid.Fields = append(invalid.Fields, FieldError{Field: key, Message: "unknown profile field"})
+		}
+	}
+	if len(invalid.Fields) > 0 {
+		return nil, invalid
+	}
+	return validated, nil
+}
+
+// MissingRequiredFields returns the required profile fields the user has not filled in yet,
+// for example because an admin added them after the user registered
+func (s *UserService) MissingRequiredFields(username string) ([]models.ProfileField, error) {
+	user, err := s.repo.FindUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	return missingRequired(s.repo.GetProfileSchema(), user.ProfileField), nil
+}
+
+// --- Profile Schema ---
+
+// GetProfileSchema fetches every profile field, built-in ones first
+func (s *UserService) GetProfileSchema() []models.ProfileField {
+	return s.repo.GetProfileSchema()
+}
+
+// AddProfileField allows an admin to add a custom field to every user's profile
+func (s *UserService) AddProfileField(admin string, field models.ProfileField) error {
+	if err := s.requireAdmin(admin); err != nil {
+		return err
+	}
+	field.Key = strings.ToLower(strings.TrimSpace(field.Key))
+	if field.Key == "" || len(field.Key) > maxProfileKeyLength {
+		return fmt.Errorf("field key must be 1 to %d characters long", maxProfileKeyLength)
+	}
+	for _, r := range field.Key {
+		if !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && r != '-' {
+			return errors.New("field key may only contain lowercase letters, digits and \"-\"")
+		}
+	}
+	field.Label = strings.TrimSpace(field.Label)
+	if field.Label == "" {
+		field.Label = field.Key
+	}
+	if field.Visibility == "" {
+		field.Visibility = "public"
+	}
+	if err := validateVisibility(field.Visibility); err != nil {
+		return err
+	}
+
+	switch field.Type {
+	case "text":
+		if field.MaxLength < 0 {
+			return errors.New("maximum length cannot be negative")
+		}
+		if field.Pattern != "" {
+			if _, err := regexp.Compile(field.Pattern); err != nil {
+				return fmt.Errorf("invalid pattern: %s", err)
+			}
+		}
+		field.Min, field.Max = 0, 0
+	case "number":
+		if field.Min > field.Max {
+			return errors.New("minimum cannot be greater than maximum")
+		}
+		field.MaxLength, field.Pattern = 0, ""
+	case "year":
+		if min, max := yearRange(field); min > max {
+			return errors.New("earliest year cannot be after the latest year")
+		}
+		field.MaxLength, field.Pattern = 0, ""
+	default:
+		return fmt.Errorf("invalid field type: %s (use text, number or year)", field.Type)
+	}
+
+	if err := s.repo.AddProfileField(field); err != nil {
+		return err
+	}
+	s.repo.AddAuditEntry(admin, "add-profile-field", field.Key,
+		fmt.Sprintf("%s field %q, required: %t, visibility: %s", field.Type, field.Label, field.Required, field.Visibility))
+	return nil
+}
+
+// RemoveProfileField allows an admin to remove a custom profile field and every value stored for it
+func (s *UserService) RemoveProfileField(admin, key string) error {
+	if err := s.requireAdmin(admin); err != nil {
+		return err
+	}
+	if err := s.repo.RemoveProfileField(key); err != nil {
+		return err
+	}
+	s.repo.AddAuditEntry(admin, "remove-profile-field", key, "")
+	return nil
+}
+
+// --- Profile Privacy ---
+
+// ProfileEntry is one field of a profile as shown to a viewer
+type ProfileEntry struct {
+	Field      models.ProfileField
+	Value      string
+	Visibility string // Who may see the value: "public", "followers" or "private"
+}
+
+// SetProfileVisibility sets who may see one of the user's profile fields
+func (s *UserService) SetProfileVisibility(username, key, visibility string) error {
+	if err := validateVisibility(visibility); err != nil {
+		return err
+	}
+	return s.repo.SetProfileVisibility(username, key, visibility)
+}
+
+// GetVisibleProfile fetches the profile fields of a user that the viewer may see, in schema order.
+// Users always see their whole profile; others see public fields, and followers-only fields if they follow the user.
+func (s *UserService) GetVisibleProfile(viewer, username string) ([]ProfileEntry, error) {
+	entries, _, err := s.profileEntries(viewer, username, false)
+	return entries, err
+}
+
+// ViewProfile fetches a user's profile for the viewer to read. Admins also see fields hidden from them;
+// if any such field has a value the access is recorded in the audit log and reported through the returned flag.
+func (s *UserService) ViewProfile(viewer, username string) ([]ProfileEntry, bool, error) {
+	username = s.resolveUsername(username)
+	entries, revealed, err := s.profileEntries(viewer, username, s.IsAdmin(viewer))
+	if err != nil || len(revealed) == 0 {
+		return entries, false, err
+	}
+	s.repo.AddAuditEntry(viewer, "view-profile-override", username, "hidden fields: "+strings.Join(revealed, ", "))
+	return entries, true, nil
+}
+
+// profileEntries returns the profile fields of a user that the viewer may see, in schema order.
+// With override set, fields hidden from the viewer are included too when they have a value,
+// and their keys are returned as revealed.
+func (s *UserService) profileEntries(viewer, username string, override bool) ([]ProfileEntry, []string, error) {
+	user, err := s.repo.FindUserByUsername(username)
+	if err != nil {
+		return nil, nil, err
+	}
+	entries, revealed := []ProfileEntry{}, []string{}
+	for _, field := range s.repo.GetProfileSchema() {
+		entry := ProfileEntry{Field: field, Value: user.ProfileField(field.Key), Visibility: user.FieldVisibility(field)}
+		if !s.canSeeProfileField(viewer, username, entry.Visibility) {
+			if !override || entry.Value == "" {
+				continue
+			}
+			revealed = append(revealed, field.Key)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, revealed, nil
+}
+
+// canSeeProfileField reports whether the viewer may see a field of the owner's profile with the given visibility
+func (s *UserService) canSeeProfileField(viewer, owner, visibility string) bool {
+	if viewer == owner {
+		return true
+	}
+	switch visibility {
+	case "public":
+		return true
+	case "followers":
+		return s.repo.IsFollowing(viewer, owner)
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"go-socket-server/models"
+	"strings"
+)
+
+// RegistrationModes are the accepted values of Config.RegistrationMode
+var RegistrationModes = []string{"open", "approval-required", "invite-only"}
+
+// ErrRegistrationPending is returned by LoginUser for an account that an admin has not approved yet
+var ErrRegistrationPending = errors.New("your account is waiting for an admin to approve it")
+
+// --- Registration ---
+
+// SignUp registers a new ordinary user according to the configured registration mode.
+// A valid invite code is required in invite-only mode; in any mode an account registered with one needs no approval.
+// It reports whether the account must be approved by an admin before it can be used.
+func (s *UserService) SignUp(username, password, inviteCode string) (bool, error) {
+	if inviteCode != "" {
+		return false, s.signUpWithInvite(username, password, inviteCode)
+	}
+	status := "approved"
+	switch s.config.RegistrationMode {
+	case "approval-required":
+		status = "pending"
+	case "invite-only":
+		return false, errors.New("registration is by invitation only, an invite code is required")
+	}
+	username, err := s.canonicalizeUsername(username, false)
+	if err != nil {
+		return false, err
+	}
+	if err := s.createUser(username, password, "user", status); err != nil {
+		return false, err
+	}
+	if status == "pending" {
+		s.notifyAdmins(fmt.Sprintf("[Registration] %s is waiting for approval", username))
+	}
+	return status == "pending", nil
+}
+
+// signUpWithInvite registers a new ordinary user with an invite code, using up one of its uses
+func (s *UserService) signUpWithInvite(username, password, inviteCode string) error {
+	username, err := s.canonicalizeUsername(username, false)
+	if err != nil {
+		return err
+	}
+	user, err := s.newUser(username, password, "user", "approved")
+	if err != nil {
+		return err
+	}
+	return s.repo.CreateUserWithInvite(user, strings.ToUpper(inviteCode))
+}
+
+// GetPendingRegistrations fetches the accounts waiting for approval, oldest first; admins only
+func (s *UserService) GetPendingRegistrations(admin string) ([]models.User, error) {
+	if err := s.requireAdmin(admin); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPendingRegistrations(), nil
+}
+
+// ApproveRegistration lets a pending account log in; admins only
+func (s *UserService) ApproveRegistration(admin, username string) error {
+	if err := s.requireAdmin(admin); err != nil {
+		return err
+	}
+	username = s.resolveUsername(username)
+	if err := s.repo.ApproveRegistration(username); err != nil {
+		return err
+	}
+	s.repo.AddAuditEntry(admin, "approve-registration", username, "")
+	return nil
+}
+
+// DenyRegistration deletes a pending account so its username can be registered again; admins only
+func (s *UserService) DenyRegistration(admin, username, reason string) error {
+	if err := s.requireAdmin(admin); err != nil {
+		return err
+	}
+	username = s.resolveUsername(username)
+	if err := s.repo.DenyRegistration(username); err != nil {
+		return err
+	}
+	s.repo.AddAuditEntry(admin, "deny-registration", username, reason)
+	return nil
+}
+
+// notifyAdmins sends a notification to every admin who is online
+func (s *UserService) notifyAdmins(text string) {
+	if s.notifier == nil {
+		return
+	}
+	for _, user := range s.repo.GetAllUsers() {
+		if user.Role == "admin" && user.Status == "approved" {
+			s.notifier.Notify(user.Username, text)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	maxTagLength   = 32
+	maxTagsPerBlog = 10
+)
+
+// normalizeTags lowercases tags, strips a leading "#", drops duplicates and empty entries,
+// and rejects tags with characters other than letters, digits and "-"
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > maxTagLength {
+			return nil, fmt.Errorf("tag %q is too long (maximum is %d characters)", tag, maxTagLength)
+		}
+		for _, r := range tag {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' {
+				return nil, fmt.Errorf("tag %q may only contain letters, digits and \"-\"", tag)
+			}
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > maxTagsPerBlog {
+		return nil, fmt.Errorf("a blog can have at most %d tags", maxTagsPerBlog)
+	}
+	return normalized, nil
+}
@@ -5,81 +5,263 @@ import (
 	"fmt"
 	"go-socket-server/models"
 	"golang.org/x/crypto/bcrypt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 )
 
 type UserService struct {
-	repo *models.InMemoryUserRepository
+	repo     *models.InMemoryUserRepository
+	config   Config
+	notifier Notifier       // Reaches users who are online, may be nil
+	sessions SessionTracker // Reports live connections, may be nil
+
+	usernamePattern *regexp.Regexp // Built from config.UsernameCharset
+	hasher          passwordHasher // Built from the password hashing settings in config
+}
+
+// NewUserService creates a new instance of UserService using the given policy settings
+func NewUserService(repo *models.InMemoryUserRepository, config Config) *UserService {
+	if config.AdminQuorum < 1 {
+		config.AdminQuorum = 1
+	}
+	if config.PageSize < 1 {
+		config.PageSize = DefaultConfig().PageSize
+	}
+	if config.MaxBlogBytes < 1 {
+		config.MaxBlogBytes = DefaultConfig().MaxBlogBytes
+	}
+	if config.MaxCommentBytes < 1 {
+		config.MaxCommentBytes = DefaultConfig().MaxCommentBytes
+	}
+	if config.MaxMessageBytes < 1 {
+		config.MaxMessageBytes = DefaultConfig().MaxMessageBytes
+	}
+	if config.ChatScrollback < 0 {
+		config.ChatScrollback = DefaultConfig().ChatScrollback
+	}
+	if config.IdleAfter <= 0 {
+		config.IdleAfter = DefaultConfig().IdleAfter
+	}
+	if config.UsernameMinLength < 1 || config.UsernameMaxLength < config.UsernameMinLength {
+		config.UsernameMinLength = DefaultConfig().UsernameMinLength
+		config.UsernameMaxLength = DefaultConfig().UsernameMaxLength
+	}
+	if config.DeletionGracePeriod < 0 {
+		config.DeletionGracePeriod = DefaultConfig().DeletionGracePeriod
+	}
+	if config.DeletedContent != "remove" && config.DeletedContent != "anonymize" {
+		config.DeletedContent = DefaultConfig().DeletedContent
+	}
+	if !slices.Contains(RegistrationModes, config.RegistrationMode) {
+		config.RegistrationMode = DefaultConfig().RegistrationMode
+	}
+	if config.InviteQuota < 0 {
+		config.InviteQuota = DefaultConfig().InviteQuota
+	}
+	if config.InviteValidity <= 0 {
+		config.InviteValidity = DefaultConfig().InviteValidity
+	}
+	if !slices.Contains(PasswordHashes, config.PasswordHash) {
+		config.PasswordHash = DefaultConfig().PasswordHash
+	}
+	if config.BcryptCost < bcrypt.MinCost || config.BcryptCost > bcrypt.MaxCost {
+		config.BcryptCost = DefaultConfig().BcryptCost
+	}
+	if config.Argon2Time < 1 {
+		config.Argon2Time = DefaultConfig().Argon2Time
+	}
+	if config.Argon2Threads < 1 || config.Argon2Threads > 255 {
+		config.Argon2Threads = DefaultConfig().Argon2Threads
+	}
+	// argon2 needs at least 8 KiB per thread, and stored hashes above argon2MaxMemoryKiB are refused
+	if config.Argon2MemoryKiB < 8*config.Argon2Threads || config.Argon2MemoryKiB > argon2MaxMemoryKiB {
+		config.Argon2MemoryKiB = DefaultConfig().Argon2MemoryKiB
+	}
+	usernamePattern, err := compileUsernamePattern(config.UsernameCharset)
+	if config.UsernameCharset == "" || err != nil {
+		config.UsernameCharset = DefaultConfig().UsernameCharset
+		usernamePattern, _ = compileUsernamePattern(config.UsernameCharset)
+	}
+	return &UserService{repo: repo, config: config, usernamePattern: usernamePattern, hasher: newPasswordHasher(config)}
 }
 
-// NewUserService creates a new instance of UserService
-func NewUserService(repo *models.InMemoryUserRepository) *UserService {
-	return &UserService{repo: repo}
+// Config returns the policy settings the service is running with
+func (s *UserService) Config() Config {
+	return s.config
 }
 
 // --- User Management ---
 
-// RegisterUser registers a new user with the specified username, password, role, and status
+// RegisterUser registers a new user with the specified username, password, role, and status.
+// The username must follow the configured rules and is stored in its canonical, lowercase form.
 func (s *UserService) RegisterUser(username, password, role, status string) error {
+	username, err := s.canonicalizeUsername(username, false)
+	if err != nil {
+		return err
+	}
+	return s.createUser(username, password, role, status)
+}
+
+// createUser hashes the password and stores a new user
+func (s *UserService) createUser(username, password, role, status string) error {
+	user, err := s.newUser(username, password, role, status)
+	if err != nil {
+		return err
+	}
+	return s.repo.CreateUser(user)
+}
+
+// newUser builds a user record with the password hashed according to the configured policy
+func (s *UserService) newUser(username, password, role, status string) (models.User, error) {
 	// Hash the password before storing it
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := s.hasher.Hash(password)
 	if err != nil {
-		return fmt.Errorf("Error hashing password: %s", err)
+		return models.User{}, fmt.Errorf("Error hashing password: %s", err)
 	}
 
 	user := models.User{
-		Username: username,
-		Password: string(hashedPassword), // Store the hashed password
-		Role:     role,
-		Status:   status, // Can be "approved" or "pending"
+		Username:     username,
+		Password:     hashedPassword, // Store the hashed password
+		Role:         role,
+		Status:       status, // Can be "approved" or "pending"
+		RegisteredAt: time.Now(),
 	}
-	return s.repo.CreateUser(user)
+	return user, nil
 }
 
-// LoginUser verifies the username and password for login
+// LoginUser verifies the username and password for login. Usernames are matched case-insensitively.
+// An account still waiting for approval is refused with ErrRegistrationPending once the password matches.
+// A password hash made under an older, weaker policy is replaced with one made under the current policy.
 func (s *UserService) LoginUser(username, password string) (models.User, error) {
+	username, err := s.repo.ResolveUsername(username)
+	if err != nil {
+		return models.User{}, err
+	}
 	user, err := s.repo.FindUserByUsername(username)
 	if err != nil {
 		return models.User{}, err
 	}
 
 	// Compare the hashed password with the password provided
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+	err = verifyPassword(user.Password, password)
 	if err != nil {
 		return models.User{}, fmt.Errorf("Invalid password")
 	}
+	if s.hasher.NeedsRehash(user.Password) {
+		// A failed upgrade leaves the old hash in place; the login itself still succeeds
+		hashedPassword, err := s.hasher.Hash(password)
+		if err == nil && s.repo.ReplacePasswordHash(username, user.Password, hashedPassword) == nil {
+			user.Password = hashedPassword
+		}
+	}
+	if user.Status == "pending" {
+		return models.User{}, ErrRegistrationPending
+	}
 
 	return user, nil
 }
 
-// UpdateUserProfile updates the profile of the user with the given information
-func (s *UserService) UpdateUserProfile(username, name, surname, favAnimal, favMovie, yearOfBirth, city, footballTeam string) error {
+// UpdateUserProfile changes only the profile fields present in changes, keyed as in the profile schema.
+// Fields that are not mentioned keep their current value; an empty value clears a field.
+// Every required field must have a value afterwards, including ones that were already empty.
+// If any value is invalid nothing is changed and a *ValidationError lists every offending field.
+func (s *UserService) UpdateUserProfile(username string, changes map[string]string) error {
+	if len(changes) == 0 {
+		return errors.New("no profile fields to update")
+	}
 	user, err := s.repo.FindUserByUsername(username)
 	if err != nil {
 		return err
 	}
-
-	// Update profile information
-	user.Name = name
-	user.Surname = surname
-	user.FavAnimal = favAnimal
-	user.FavMovie = favMovie
-	user.YearOfBirth = yearOfBirth
-	user.CityOfBirth = city
-	user.FootballTeam = footballTeam
-
-	// Save the updated user back to the repository
-	return s.repo.UpdateUser(user)
+	schema := s.repo.GetProfileSchema()
+	validated, err := validateProfile(schema, changes)
+	if err != nil {
+		return err
+	}
+	missing := missingRequired(schema, func(key string) string {
+		if value, changed := validated[key]; changed {
+			return value
+		}
+		return user.ProfileField(key)
+	})
+	if len(missing) > 0 {
+		invalid := &ValidationError{}
+		for _, field := range missing {
+			invalid.Fields = append(invalid.Fields, FieldError{Field: field.Key, Message: "is required"})
+		}
+		return invalid
+	}
+	return s.repo.UpdateProfileFields(username, validated)
 }
 
 // --- Blog Management ---
 
-// CreateBlog allows a user to create a new blog with the specified title and text
-func (s *UserService) CreateBlog(username, title, text string) error {
+// CreateBlog allows a user to create a new blog with the specified title, text and tags.
+// The status is "published", "draft" or "scheduled"; scheduled blogs are published at publishAt.
+// The visibility is "public", "followers" or "private".
+func (s *UserService) CreateBlog(username, title, text string, tags []string, status, visibility string, publishAt time.Time) error {
 	_, err := s.repo.FindUserByUsername(username)
 	if err != nil {
 		return err
 	}
-	return s.repo.CreateBlog(username, title, text)
+	text, err = s.normalizeBlogText(text)
+	if err != nil {
+		return err
+	}
+	tags, err = normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+	if err := validateSchedule(status, publishAt); err != nil {
+		return err
+	}
+	if err := validateVisibility(visibility); err != nil {
+		return err
+	}
+	return s.repo.CreateBlog(username, title, text, tags, status, visibility, publishAt)
+}
+
+// SetBlogTags replaces the tags of a user's own blog post
+func (s *UserService) SetBlogTags(username, blogID string, tags []string) error {
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+	return s.repo.SetBlogTags(username, blogID, tags)
+}
+
+// GetPopularTags returns every tag with the number of blogs carrying it, most used first.
+// Only published blogs the viewer may read are counted, so drafts and private posts do not reveal their tags.
+func (s *UserService) GetPopularTags(viewer string) []models.TagCount {
+	tags := []models.TagCount{}
+	for tag, blogs := range s.repo.GetTaggedBlogs() {
+		if count := len(s.visibleBlogs(viewer, blogs)); count > 0 {
+			tags = append(tags, models.TagCount{Tag: tag, Count: count})
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+	return tags
+}
+
+// GetBlogsByTag fetches one page of the blogs carrying a tag, newest first, along with the total number of pages
+func (s *UserService) GetBlogsByTag(viewer, tag string, page int) ([]models.Blog, int, error) {
+	normalized, err := normalizeTags([]string{tag})
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(normalized) == 0 {
+		return nil, 0, errors.New("tag cannot be empty")
+	}
+	blogs, pages := paginate(s.visibleBlogs(viewer, s.repo.GetBlogsByTag(normalized[0])), page, s.config.PageSize)
+	return blogs, pages, nil
 }
 
 // DeleteBlog allows a user to delete their own blog post
@@ -87,11 +269,196 @@ func (s *UserService) DeleteBlog(username, blogID string) error {
 	return s.repo.DeleteBlog(username, blogID)
 }
 
+// EditBlog allows a user to change the title and text of their own blog post, keeping the old version as a revision
+func (s *UserService) EditBlog(username, blogID, title, text string) error {
+	if title == "" || text == "" {
+		return errors.New("title and text cannot be empty")
+	}
+	text, err := s.normalizeBlogText(text)
+	if err != nil {
+		return err
+	}
+	return s.repo.UpdateBlog(username, blogID, title, text)
+}
+
+// normalizeBlogText converts line endings to "\n" and enforces the configured maximum body size
+func (s *UserService) normalizeBlogText(text string) (string, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if len(text) > s.config.MaxBlogBytes {
+		return "", fmt.Errorf("blog text is too long (%d bytes, maximum is %d)", len(text), s.config.MaxBlogBytes)
+	}
+	return text, nil
+}
+
+// RestoreBlogRevision allows a user to restore an earlier revision of their own blog post
+func (s *UserService) RestoreBlogRevision(username, blogID string, revision int) error {
+	return s.repo.RestoreBlogRevision(username, blogID, revision)
+}
+
+// GetBlog fetches a single blog by its ID without any visibility checks, for use by the blog's author
+func (s *UserService) GetBlog(blogID string) (models.Blog, error) {
+	return s.repo.FindBlogByID(blogID)
+}
+
+// ViewBlog fetches a single blog by its ID if the viewer is allowed to read it
+func (s *UserService) ViewBlog(viewer, blogID string) (models.Blog, error) {
+	blog, err := s.repo.FindBlogByID(blogID)
+	if err != nil || !s.canView(viewer, blog) {
+		return models.Blog{}, errors.New("Blog not found")
+	}
+	return blog, nil
+}
+
 // GetBlogsByUser fetches all blogs written by a specific user
 func (s *UserService) GetBlogsByUser(username string) []models.Blog {
 	return s.repo.GetBlogsByUser(username)
 }
 
+// GetFeed fetches one page of the global blog feed, newest first, along with the total number of pages
+func (s *UserService) GetFeed(viewer string, page int) ([]models.Blog, int) {
+	return paginate(s.visibleBlogs(viewer, s.repo.GetAllBlogs()), page, s.config.PageSize)
+}
+
+// GetUserPosts fetches one page of a user's blogs, newest first, along with the total number of pages
+func (s *UserService) GetUserPosts(viewer, username string, page int) ([]models.Blog, int, error) {
+	username = s.resolveUsername(username)
+	if _, err := s.repo.FindUserByUsername(username); err != nil {
+		return nil, 0, err
+	}
+	blogs, pages := paginate(s.visibleBlogs(viewer, s.repo.GetBlogsByUser(username)), page, s.config.PageSize)
+	return blogs, pages, nil
+}
+
+// --- Comments ---
+
+// AddComment allows a user to comment on a blog post
+func (s *UserService) AddComment(username, blogID, text string) error {
+	if err := s.validateComment(text); err != nil {
+		return err
+	}
+	if _, err := s.ViewBlog(username, blogID); err != nil {
+		return err
+	}
+	_, err := s.repo.CreateComment(blogID, "", username, text)
+	return err
+}
+
+// ReplyToComment allows a user to reply to an existing comment
+func (s *UserService) ReplyToComment(username, commentID, text string) error {
+	if err := s.validateComment(text); err != nil {
+		return err
+	}
+	parent, err := s.repo.FindCommentByID(commentID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.ViewBlog(username, parent.BlogID); err != nil {
+		return err
+	}
+	_, err = s.repo.CreateComment(parent.BlogID, parent.ID, username, text)
+	return err
+}
+
+// EditComment allows a user to change the text of their own comment
+func (s *UserService) EditComment(username, commentID, text string) error {
+	if err := s.validateComment(text); err != nil {
+		return err
+	}
+	return s.repo.UpdateComment(username, commentID, text)
+}
+
+// DeleteComment removes a comment. It is allowed for the comment's author, the blog's author and admins.
+func (s *UserService) DeleteComment(username, commentID string) error {
+	comment, err := s.repo.FindCommentByID(commentID)
+	if err != nil {
+		return err
+	}
+	if comment.Author != username && !s.IsAdmin(username) {
+		blog, err := s.repo.FindBlogByID(comment.BlogID)
+		if err != nil || blog.Author != username {
+			return errors.New("you are not allowed to delete this comment")
+		}
+	}
+	return s.repo.DeleteComment(commentID)
+}
+
+// GetComments fetches every comment on a blog post, oldest first
+func (s *UserService) GetComments(viewer, blogID string) ([]models.Comment, error) {
+	if _, err := s.ViewBlog(viewer, blogID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetCommentsByBlog(blogID), nil
+}
+
+// CountComments returns the number of visible comments on a blog post
+func (s *UserService) CountComments(blogID string) int {
+	return s.repo.CountComments(blogID)
+}
+
+// validateComment checks that a comment is not empty and within the configured size
+func (s *UserService) validateComment(text string) error {
+	if strings.TrimSpace(text) == "" {
+		return errors.New("comment cannot be empty")
+	}
+	if len(text) > s.config.MaxCommentBytes {
+		return fmt.Errorf("comment is too long (%d bytes, maximum is %d)", len(text), s.config.MaxCommentBytes)
+	}
+	return nil
+}
+
+// --- Search ---
+
+// Search fetches one page of blogs and users matching the query, best match first, along with the total number of pages.
+// Blogs the viewer may not read are left out, as are accounts still waiting for approval or scheduled for deletion.
+func (s *UserService) Search(viewer, query string, page int) ([]models.SearchResult, int, error) {
+	if strings.TrimSpace(strings.ReplaceAll(query, "\"", "")) == "" {
+		return nil, 0, errors.New("search query cannot be empty")
+	}
+	visible := []models.SearchResult{}
+	for _, result := range s.repo.Search(query) {
+		if result.Kind == "blog" {
+			blog, err := s.repo.FindBlogByID(result.ID)
+			if err != nil || len(s.visibleBlogs(viewer, []models.Blog{blog})) == 0 {
+				continue
+			}
+		}
+		if result.Kind == "user" {
+			user, err := s.repo.FindUserByUsername(result.ID)
+			if err != nil || !isListed(user) {
+				continue
+			}
+		}
+		visible = append(visible, result)
+	}
+	results, pages := paginate(visible, page, s.config.PageSize)
+	return results, pages, nil
+}
+
+// --- Reactions ---
+
+// React sets the user's reaction to a blog post, replacing any reaction they left before
+func (s *UserService) React(username, blogID, kind string) error {
+	if _, err := s.ViewBlog(username, blogID); err != nil {
+		return err
+	}
+	return s.repo.SetReaction(blogID, username, kind)
+}
+
+// Unreact removes the user's reaction from a blog post
+func (s *UserService) Unreact(username, blogID string) error {
+	return s.repo.RemoveReaction(blogID, username)
+}
+
+// CountReactions returns the number of reactions of each kind on a blog post
+func (s *UserService) CountReactions(blogID string) map[string]int {
+	return s.repo.CountReactions(blogID)
+}
+
+// GetLikedBlogs fetches one page of the blogs a user has liked, newest first, along with the total number of pages
+func (s *UserService) GetLikedBlogs(username string, page int) ([]models.Blog, int) {
+	return paginate(s.visibleBlogs(username, s.repo.GetReactedBlogs(username, "like")), page, s.config.PageSize)
+}
+
 // --- Admin Management ---
 
 // GetAllUsers returns all users in the system
@@ -99,43 +466,100 @@ func (s *UserService) GetAllUsers() []models.User {
 	return s.repo.GetAllUsers()
 }
 
-// DeleteUser removes a user from the system
+// DeleteUser removes a user from the system, handling their content according to the configured policy
 func (s *UserService) DeleteUser(username string) error {
-	return s.repo.DeleteUser(username)
+	username = s.resolveUsername(username)
+	return s.repo.DeleteUser(username, s.config.DeletedContent == "anonymize")
 }
 
-// GetPendingAdminApprovals fetches users who have applied for admin but are pending approval
-func (s *UserService) GetPendingAdminApprovals() []models.User {
-	return s.repo.GetPendingAdmins()
+// GetPendingAdminApprovals fetches admin applications that are still awaiting a decision
+func (s *UserService) GetPendingAdminApprovals() []models.AdminApplication {
+	return s.repo.GetPendingApplications()
 }
 
-// ApproveAdminRequest approves a user's request to become an admin
-func (s *UserService) ApproveAdminRequest(username string) error {
-	return s.repo.ApproveAdmin(username)
+// ApproveAdminRequest records an admin's approval of a user's pending admin application.
+// The applicant is granted the admin role once the configured number of distinct admins have approved.
+// It returns the number of approvals collected so far and the number required.
+func (s *UserService) ApproveAdminRequest(username, decidedBy, reason string) (int, int, error) {
+	username = s.resolveUsername(username)
+	if err := s.requireAdmin(decidedBy); err != nil {
+		return 0, 0, err
+	}
+	return s.repo.ApproveAdminApplication(username, decidedBy, reason, s.config.AdminQuorum)
 }
 
-// RejectAdminRequest rejects a user's request to become an admin
-func (s *UserService) RejectAdminRequest(username string) error {
-	return s.repo.RejectAdmin(username)
+// RejectAdminRequest rejects a user's pending admin application, leaving their role unchanged
+func (s *UserService) RejectAdminRequest(username, decidedBy, reason string) error {
+	username = s.resolveUsername(username)
+	if err := s.requireAdmin(decidedBy); err != nil {
+		return err
+	}
+	return s.repo.DecideAdminApplication(username, decidedBy, "rejected", reason)
 }
 
-// ApplyForAdmin marks a user's status as "pending admin approval"
-func (us *UserService) ApplyForAdmin(username string) error {
-	user, exists := us.repo.Users[username]
-	if !exists {
-		return errors.New("user does not exist")
+// DemoteAdmin records an admin's vote to remove the admin role from another admin.
+// The target is demoted once the configured number of distinct admins, not counting the target, have voted.
+// It returns the number of votes collected so far and the number required.
+func (s *UserService) DemoteAdmin(target, requestedBy, reason string) (int, int, error) {
+	target = s.resolveUsername(target)
+	if err := s.requireAdmin(requestedBy); err != nil {
+		return 0, 0, err
+	}
+	if target == requestedBy {
+		return 0, 0, errors.New("you cannot vote on your own demotion")
 	}
+	return s.repo.VoteDemotion(target, requestedBy, reason, s.config.AdminQuorum)
+}
+
+// GetPendingDemotions fetches demotion requests that have not reached their quorum yet
+func (s *UserService) GetPendingDemotions() []models.DemotionRequest {
+	return s.repo.GetPendingDemotions()
+}
 
-	if user.Status == "pending" && user.Role == "admin" {
-		return errors.New("admin application already pending")
+// ErrAdminExists is returned by BootstrapAdmin once the server has an admin
+var ErrAdminExists = errors.New("an admin already exists")
+
+// BootstrapAdmin creates the first admin account. It is refused with ErrAdminExists once any admin exists.
+func (s *UserService) BootstrapAdmin(username, password string) error {
+	if s.repo.CountAdmins() > 0 {
+		return ErrAdminExists
 	}
+	// The operator may pick a reserved name such as "admin" for the first admin account
+	username, err := s.canonicalizeUsername(username, true)
+	if err != nil {
+		return err
+	}
+	return s.createUser(username, password, "admin", "approved")
+}
 
-	// Mark the user as applying for admin status
-	user.Status = "pending"
-	user.Role = "admin"
-	us.repo.Users[username] = user
+// IsAdmin reports whether the user currently holds the admin role
+func (s *UserService) IsAdmin(username string) bool {
+	return s.requireAdmin(username) == nil
+}
+
+// requireAdmin returns an error unless the user currently holds the admin role
+func (s *UserService) requireAdmin(username string) error {
+	user, err := s.repo.FindUserByUsername(username)
+	if err != nil || user.Role != "admin" || user.Status != "approved" {
+		return errors.New("you do not have permission to perform this action")
+	}
 	return nil
 }
+
+// ApplyForAdmin files a new admin application for the user with the given motivation
+func (s *UserService) ApplyForAdmin(username, motivation string) error {
+	if motivation == "" {
+		return errors.New("motivation is required")
+	}
+	_, err := s.repo.CreateAdminApplication(username, motivation)
+	return err
+}
+
+// GetAdminApplications returns the admin application history of a user
+func (s *UserService) GetAdminApplications(username string) []models.AdminApplication {
+	return s.repo.GetApplicationsByUser(username)
+}
+
 func (s *UserService) FindUserByUsername(username string) (models.User, error) {
 	user, err := s.repo.FindUserByUsername(username)
 	if err != nil {
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"fmt"
+	"go-socket-server/models"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// UsernameIssue describes an existing account whose username breaks the current username rules
+type UsernameIssue struct {
+	Username string
+	Problem  string
+}
+
+// compileUsernamePattern builds the expression a canonical username must match from a character class
+func compileUsernamePattern(charset string) (*regexp.Regexp, error) {
+	return regexp.Compile("^[" + charset + "]+$")
+}
+
+// canonicalizeUsername trims a new username and converts it to its canonical form,
+// rejecting it if it breaks the configured rules. Reserved names are only accepted when allowReserved is set.
+func (s *UserService) canonicalizeUsername(username string, allowReserved bool) (string, error) {
+	canonical := models.CanonicalUsername(strings.TrimSpace(username))
+	if err := s.checkUsername(canonical, allowReserved); err != nil {
+		return "", err
+	}
+	return canonical, nil
+}
+
+// checkUsername checks a canonical username against the configured length, character set and reserved names
+func (s *UserService) checkUsername(canonical string, allowReserved bool) error {
+	length := utf8.RuneCountInString(canonical)
+	if length < s.config.UsernameMinLength || length > s.config.UsernameMaxLength {
+		return fmt.Errorf("username must be %d to %d characters long", s.config.UsernameMinLength, s.config.UsernameMaxLength)
+	}
+	if !s.usernamePattern.MatchString(canonical) {
+		return fmt.Errorf("username may only contain the characters [%s]", s.config.UsernameCharset)
+	}
+	if !allowReserved {
+		for _, reserved := range s.config.ReservedUsernames {
+			if canonical == models.CanonicalUsername(reserved) {
+				return fmt.Errorf("username %q is reserved", canonical)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveUsername returns the stored username a name typed by a user refers to, matching case-insensitively.
+// A name that matches no single account is returned unchanged, so the caller reports it as not found as usual.
+func (s *UserService) resolveUsername(username string) string {
+	if resolved, err := s.repo.ResolveUsername(username); err == nil {
+		return resolved
+	}
+	return username
+}
+
+// GetUsernameReport fetches the identities whose usernames collide case-insensitively and the accounts
+// whose usernames break the current rules; admins only
+func (s *UserService) GetUsernameReport(admin string) ([]models.UsernameCollision, []UsernameIssue, error) {
+	if err := s.requireAdmin(admin); err != nil {
+		return nil, nil, err
+	}
+	issues := []UsernameIssue{}
+	for _, user := range s.repo.GetAllUsers() {
+		canonical := models.CanonicalUsername(user.Username)
+		if canonical != user.Username {
+			issues = append(issues, UsernameIssue{Username: user.Username, Problem: "not in canonical form " + canonical})
+		}
+		if err := s.checkUsername(canonical, user.Role == "admin"); err != nil {
+			issues = append(issues, UsernameIssue{Username: user.Username, Problem: err.Error()})
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		return issues[i].Username < issues[j].Username
+	})
+	return s.repo.FindUsernameCollisions(), issues, nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// writeTimeout is how long a client may take to accept output before its connection is closed.
+// Other users' goroutines write notifications to the connection, so a client that stops reading must not hold them up.
+const writeTimeout = 10 * time.Second
+
+// sessionWriter wraps the buffered writer of a client connection so that the connection's own
+// command handler and other goroutines pushing notifications never write to it at the same time.
+// It remembers the unfinished line last shown to the client, usually a prompt such as "Title: ",
+// so that a notification arriving while the client is answering it can print the prompt again.
+type sessionWriter struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	writer  *bufio.Writer
+	pending string // Text written after the last newline, still on the client's current line
+
+	activityMu   sync.Mutex // Guards lastActivity on its own so presence checks never wait for a write
+	lastActivity time.Time  // When the client last sent input
+}
+
+// newSessionWriter creates a sessionWriter for a client connection
+func newSessionWriter(conn net.Conn) *sessionWriter {
+	return &sessionWriter{conn: conn, writer: bufio.NewWriter(conn), lastActivity: time.Now()}
+}
+
+// WriteString buffers text for the client
+func (sw *sessionWriter) WriteString(text string) (int, error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if i := strings.LastIndex(text, "\n"); i >= 0 {
+		sw.pending = text[i+1:]
+	} else {
+		sw.pending += text
+	}
+	// A full buffer is flushed to the connection while writing
+	sw.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return sw.writer.WriteString(text)
+}
+
+// Flush sends buffered text to the client
+func (sw *sessionWriter) Flush() error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.flush()
+}
+
+// Notify sends a line to the client immediately, on its own line.
+// If a prompt is waiting for an answer it is shown again below the notification.
+// A client that does not accept it within writeTimeout is disconnected and an error is returned.
+func (sw *sessionWriter) Notify(text string) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	sw.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if sw.pending != "" {
+		sw.writer.WriteString("\n" + text + "\n" + sw.pending)
+	} else {
+		sw.writer.WriteString(text + "\n")
+	}
+	return sw.flush()
+}
+
+// flush sends buffered text to the client within writeTimeout; the caller must hold mu.
+// After a failed write the output is incomplete and the writer unusable, so the connection is closed,
+// which also ends the client's command handler.
+func (sw *sessionWriter) flush() error {
+	sw.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	err := sw.writer.Flush()
+	if err != nil {
+		sw.conn.Close()
+	}
+	return err
+}
+
+// inputReceived records that the client sent a line, which leaves its cursor at the start of a new line
+func (sw *sessionWriter) inputReceived() {
+	sw.activityMu.Lock()
+	sw.lastActivity = time.Now()
+	sw.activityMu.Unlock()
+
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	sw.pending = ""
+}
+
+// LastActivity returns when the client last sent input
+func (sw *sessionWriter) LastActivity() time.Time {
+	sw.activityMu.Lock()
+	defer sw.activityMu.Unlock()
+	return sw.lastActivity
+}
+
+// sessionInput reads from a client connection and lets the session writer know whenever input arrives
+type sessionInput struct {
+	conn   net.Conn
+	writer *sessionWriter
+}
+
+// Read reads input from the client connection
+func (in sessionInput) Read(p []byte) (int, error) {
+	n, err := in.conn.Read(p)
+	if n > 0 {
+		in.writer.inputReceived()
+	}
+	return n, err
+}
+
+// sessions holds the writers of every logged-in connection by username, guarded by mu
+var sessions = make(map[string][]*sessionWriter)
+
+// registerSession records that a connection is logged in as the user
+func registerSession(username string, writer *sessionWriter) {
+	mu.Lock()
+	defer mu.Unlock()
+	sessions[username] = append(sessions[username], writer)
+}
+
+// unregisterSession forgets a connection of the user when it logs out or disconnects
+func unregisterSession(username string, writer *sessionWriter) {
+	mu.Lock()
+	defer mu.Unlock()
+	remaining := []*sessionWriter{}
+	for _, existing := range sessions[username] {
+		if existing != writer {
+			remaining = append(remaining, existing)
+		}
+	}
+	if len(remaining) == 0 {
+		delete(sessions, username)
+	} else {
+		sessions[username] = remaining
+	}
+}
+
+// sessionTracker gives the user service access to the live connections: it delivers notifications
+// and reports presence
+type sessionTracker struct{}
+
+// Notify pushes text to every connection the user is logged in on and reports whether there was any
+func (sessionTracker) Notify(username, text string) bool {
+	mu.Lock()
+	writers := append([]*sessionWriter{}, sessions[username]...)
+	mu.Unlock()
+
+	delivered := false
+	for _, writer := range writers {
+		if writer.Notify(text) == nil {
+			delivered = true
+		}
+	}
+	return delivered
+}
+
+// LastActivity returns the most recent input on any of the user's connections, and false if they have none
+func (sessionTracker) LastActivity(username string) (time.Time, bool) {
+	mu.Lock()
+	writers := append([]*sessionWriter{}, sessions[username]...)
+	mu.Unlock()
+
+	var latest time.Time
+	for _, writer := range writers {
+		if activity := writer.LastActivity(); activity.After(latest) {
+			latest = activity
+		}
+	}
+	return latest, len(writers) > 0
+}
+
+// OnlineUsers returns the usernames with at least one live connection
+func (sessionTracker) OnlineUsers() []string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	usernames := []string{}
+	for username := range sessions {
+		usernames = append(usernames, username)
+	}
+	return usernames
+}